@@ -9,6 +9,7 @@ type Aggregate struct {
 }
 
 func (a Aggregate) selectable() {}
+func (a Aggregate) expr()       {}
 
 func (a Aggregate) As(alias string) Aggregate {
 	return Aggregate{
@@ -52,3 +53,53 @@ func Min(col string) Aggregate {
 		arg: col,
 	}
 }
+
+// Eq 例如 Avg("Age").Eq(18)
+// 一般用在 HAVING 里面
+func (a Aggregate) Eq(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opEq,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) LT(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opLT,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) GT(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opGT,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) NEQ(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opNEQ,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) LE(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opLE,
+		right: valueOf(arg),
+	}
+}
+
+func (a Aggregate) GE(arg any) Predicate {
+	return Predicate{
+		left:  a,
+		op:    opGE,
+		right: valueOf(arg),
+	}
+}
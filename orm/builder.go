@@ -11,6 +11,16 @@ type builder struct {
 	sb     strings.Builder
 	args   []any
 	quoter byte
+	// argOffset 作为子查询的时候，外层查询已经有的参数个数
+	// 用于计算 PostgreSQL 的 $n 占位符
+	argOffset int
+}
+
+// reset 清空上一次构造的结果
+// middleware 可能会在真正执行之前调用 Build，所以 Build 必须能够重复调用
+func (b *builder) reset() {
+	b.sb.Reset()
+	b.args = nil
 }
 
 func (b *builder) quote(name string) {
@@ -56,6 +66,53 @@ func (b *builder) buildColumn(c Column) error {
 	return nil
 }
 
+// parameter 写入占位符，并且记录参数
+// 占位符由方言决定，例如 MySQL 是 ?，PostgreSQL 是 $1, $2
+func (b *builder) parameter(arg any) {
+	b.addArg(arg)
+	b.sb.WriteString(b.dialect.placeholder(b.argOffset + len(b.args)))
+}
+
+// buildRaw 原生表达式里面的 ? 也要换成方言的占位符
+// 多出来的参数原样追加，保证参数的个数不变
+func (b *builder) buildRaw(raw RawExpr) {
+	args := raw.args
+	for i := 0; i < len(raw.raw); i++ {
+		if raw.raw[i] == '?' && len(args) > 0 {
+			b.parameter(args[0])
+			args = args[1:]
+			continue
+		}
+		b.sb.WriteByte(raw.raw[i])
+	}
+	b.addArg(args...)
+}
+
+func (b *builder) setArgOffset(offset int) {
+	b.argOffset = offset
+}
+
+// buildSubquery 构造子查询，子查询的参数接在当前参数后面
+func (b *builder) buildSubquery(sub Subquery) error {
+	sub.s.setArgOffset(b.argOffset + len(b.args))
+	q, err := sub.s.Build()
+	if err != nil {
+		return err
+	}
+	b.sb.WriteByte('(')
+	b.sb.WriteString(strings.TrimSuffix(q.SQL, ";"))
+	b.sb.WriteByte(')')
+	b.addArg(q.Args...)
+	return nil
+}
+
+func (b *builder) buildAs(alias string) {
+	if alias != "" {
+		b.sb.WriteString(" AS ")
+		b.quote(alias)
+	}
+}
+
 func (b *builder) addArg(vals ...any) {
 	if len(vals) == 0 {
 		return
@@ -65,3 +122,110 @@ func (b *builder) addArg(vals ...any) {
 	}
 	b.args = append(b.args, vals...)
 }
+
+func (b *builder) buildPredicates(ps []Predicate) error {
+	p := ps[0]
+	for i := 1; i < len(ps); i++ {
+		p = p.And(ps[i])
+	}
+	return b.buildExpression(p)
+}
+
+func (b *builder) buildExpression(expr Expression) error {
+	switch exp := expr.(type) {
+	case nil:
+	case Predicate:
+		return b.buildBinary(exp.left, exp.op, exp.right)
+	case MathExpr:
+		return b.buildBinary(exp.left, exp.op, exp.right)
+	case FuncExpr:
+		b.sb.WriteString(exp.fn)
+		b.sb.WriteByte('(')
+		for i, arg := range exp.args {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			if err := b.buildExpression(arg); err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
+	case Column:
+		// 这种写法很隐晦
+		exp.alias = ""
+		return b.buildColumn(exp)
+	case Aggregate:
+		return b.buildAggregate(exp)
+	case value:
+		b.parameter(exp.val)
+	case values:
+		if len(exp) == 0 {
+			return errs.ErrEmptyValues
+		}
+		// IN (?,?,?)
+		b.sb.WriteByte('(')
+		for i, val := range exp {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.parameter(val)
+		}
+		b.sb.WriteByte(')')
+	case betweenValues:
+		b.parameter(exp.low)
+		b.sb.WriteString(" AND ")
+		b.parameter(exp.high)
+	case Subquery:
+		return b.buildSubquery(exp)
+	case RawExpr:
+		b.sb.WriteByte('(')
+		b.buildRaw(exp)
+		b.sb.WriteByte(')')
+	default:
+		return errs.NewErrUnsupportedExpression(expr)
+	}
+	return nil
+}
+
+// buildBinary 构造 left op right
+// 左右两边如果也是 Predicate 或者 MathExpr，就要加括号
+func (b *builder) buildBinary(left Expression, o op, right Expression) error {
+	if err := b.buildSubExpr(left); err != nil {
+		return err
+	}
+	if o != "" {
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(o.String())
+	}
+	// IS NULL 这一类是没有右边的
+	if right == nil {
+		return nil
+	}
+	b.sb.WriteByte(' ')
+	return b.buildSubExpr(right)
+}
+
+func (b *builder) buildSubExpr(expr Expression) error {
+	switch expr.(type) {
+	case Predicate, MathExpr:
+		b.sb.WriteByte('(')
+		if err := b.buildExpression(expr); err != nil {
+			return err
+		}
+		b.sb.WriteByte(')')
+		return nil
+	default:
+		return b.buildExpression(expr)
+	}
+}
+
+// buildAggregate 构造聚合函数，不处理别名
+func (b *builder) buildAggregate(a Aggregate) error {
+	b.sb.WriteString(a.fn)
+	b.sb.WriteByte('(')
+	if err := b.buildColumn(Column{name: a.arg}); err != nil {
+		return err
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
@@ -0,0 +1,87 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+var _ Session = &Cluster{}
+
+// Cluster 读写分离，一个主库加上多个从库
+// 写操作和事务都在主库上执行，查询在从库之间轮询
+// ctx 里面有主库的事务的时候，读写都在这个事务里面执行
+// 方言、元数据注册中心和 middleware 都使用主库的，从库的这些配置会被忽略
+type Cluster struct {
+	primary  *DB
+	replicas []*DB
+	cnt      uint32
+}
+
+func NewCluster(primary *DB, replicas ...*DB) *Cluster {
+	return &Cluster{
+		primary:  primary,
+		replicas: replicas,
+	}
+}
+
+type usePrimaryKey struct{}
+
+// UsePrimary 强制查询走主库
+// 例如刚写入的数据，从库可能还没有同步，这时候就需要从主库读
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usePrimaryKey{}, true)
+}
+
+func (c *Cluster) getCore() core {
+	return c.primary.core
+}
+
+func (c *Cluster) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if tx, ok := c.primary.txFrom(ctx); ok {
+		return tx.queryContext(ctx, query, args...)
+	}
+	return c.read(ctx).queryContext(ctx, query, args...)
+}
+
+func (c *Cluster) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if tx, ok := c.primary.txFrom(ctx); ok {
+		return tx.execContext(ctx, query, args...)
+	}
+	return c.primary.execContext(ctx, query, args...)
+}
+
+// read 选择执行查询的库
+func (c *Cluster) read(ctx context.Context) *DB {
+	if len(c.replicas) == 0 {
+		return c.primary
+	}
+	if usePrimary, _ := ctx.Value(usePrimaryKey{}).(bool); usePrimary {
+		return c.primary
+	}
+	idx := atomic.AddUint32(&c.cnt, 1)
+	return c.replicas[int(idx)%len(c.replicas)]
+}
+
+// BeginTx 事务只会在主库上开启
+func (c *Cluster) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	return c.primary.BeginTx(ctx, opts)
+}
+
+func (c *Cluster) DoTx(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	return c.primary.DoTx(ctx, fn, opts)
+}
+
+func (c *Cluster) DoTxWithPropagation(ctx context.Context, propagation Propagation,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	return c.primary.DoTxWithPropagation(ctx, propagation, fn, opts)
+}
+
+func (c *Cluster) DoTxWithRetry(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions, retryOpts ...TxRetryOption) error {
+	return c.primary.DoTxWithRetry(ctx, fn, opts, retryOpts...)
+}
@@ -0,0 +1,122 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster(t *testing.T) {
+	newDB := func() (*DB, sqlmock.Sqlmock) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = mockDB.Close()
+		})
+		db, err := OpenDB(mockDB)
+		require.NoError(t, err)
+		return db, mock
+	}
+	primary, primaryMock := newDB()
+	replica1, replica1Mock := newDB()
+	replica2, replica2Mock := newDB()
+	cluster := NewCluster(primary, replica1, replica2)
+	ctx := context.Background()
+
+	rows := func(name string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, name)
+	}
+
+	// 查询在从库之间轮询
+	replica2Mock.ExpectQuery("SELECT .*").WillReturnRows(rows("replica2"))
+	replica1Mock.ExpectQuery("SELECT .*").WillReturnRows(rows("replica1"))
+	replica2Mock.ExpectQuery("SELECT .*").WillReturnRows(rows("replica2"))
+	for _, want := range []string{"replica2", "replica1", "replica2"} {
+		res, err := NewSelector[TestModel](cluster).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, res.FirstName)
+	}
+
+	// 强制走主库
+	primaryMock.ExpectQuery("SELECT .*").WillReturnRows(rows("primary"))
+	res, err := NewSelector[TestModel](cluster).Get(UsePrimary(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, "primary", res.FirstName)
+
+	// 写操作走主库
+	primaryMock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	ins := NewInserter[TestModel](cluster).Values(&TestModel{Id: 1}).Exec(ctx)
+	require.NoError(t, ins.Err())
+
+	// 事务里面的查询也走主库
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectQuery("SELECT .*").WillReturnRows(rows("primary"))
+	primaryMock.ExpectCommit()
+	err = cluster.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		res, err := NewSelector[TestModel](tx).Get(ctx)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, "primary", res.FirstName)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replica1Mock.ExpectationsWereMet())
+	assert.NoError(t, replica2Mock.ExpectationsWereMet())
+}
+
+func TestCluster_Tx(t *testing.T) {
+	primary, err := Open("sqlite3", "file:cluster_tx.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer primary.Close()
+	_, err = primary.db.Exec("CREATE TABLE savepoint_model(id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	// 从库没有任何预期，查询落到从库上就会失败
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	replica, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	cluster := NewCluster(primary, replica)
+	ctx := context.Background()
+
+	// ctx 里面有事务，直接用 Cluster 读写也在这个事务里面执行
+	bizErr := errors.New("biz error")
+	err = cluster.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		err := NewInserter[SavepointModel](cluster).Values(&SavepointModel{Id: 1}).Exec(ctx).Err()
+		require.NoError(t, err)
+		// 能读到事务里面还没有提交的数据
+		res, err := NewSelector[SavepointModel](cluster).Where(C("Id").Eq(1)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), res.Id)
+		return bizErr
+	}, nil)
+	assert.True(t, errors.Is(err, bizErr))
+
+	// 写操作跟着事务一起回滚了
+	_, err = NewSelector[SavepointModel](cluster).Where(C("Id").Eq(1)).Get(UsePrimary(ctx))
+	assert.Equal(t, ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCluster_NoReplicas(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, "Tom"))
+	res, err := NewSelector[TestModel](NewCluster(db)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Tom", res.FirstName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
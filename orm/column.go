@@ -31,6 +31,112 @@ func (c Column) Eq(arg any) Predicate {
 	}
 }
 
+// NEQ 代表不等于
+func (c Column) NEQ(arg any) Predicate {
+	return c.binary(opNEQ, arg)
+}
+
+func (c Column) LT(arg any) Predicate {
+	return c.binary(opLT, arg)
+}
+
+func (c Column) LE(arg any) Predicate {
+	return c.binary(opLE, arg)
+}
+
+func (c Column) GT(arg any) Predicate {
+	return c.binary(opGT, arg)
+}
+
+func (c Column) GE(arg any) Predicate {
+	return c.binary(opGE, arg)
+}
+
+// Like 例如 C("FirstName").Like("Tom%")
+func (c Column) Like(pattern string) Predicate {
+	return c.binary(opLike, pattern)
+}
+
+func (c Column) NotLike(pattern string) Predicate {
+	return c.binary(opNotLike, pattern)
+}
+
+// In 有两种用法
+// 一种是普通的值 C("Id").In(1, 2, 3)
+// 一种是子查询 C("Id").In(sub)
+func (c Column) In(vals ...any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opIn,
+		right: inValuesOf(vals),
+	}
+}
+
+func (c Column) NotIn(vals ...any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNotIn,
+		right: inValuesOf(vals),
+	}
+}
+
+// Between 例如 C("Age").Between(18, 30)，两端都包含
+func (c Column) Between(low, high any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opBetween,
+		right: betweenValues{low: low, high: high},
+	}
+}
+
+func (c Column) IsNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNull,
+	}
+}
+
+func (c Column) IsNotNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNotNull,
+	}
+}
+
+// Add 例如 C("Age").Add(1)，可以用在 SELECT，WHERE 和赋值语句里
+func (c Column) Add(val any) MathExpr {
+	return mathOf(c, opAdd, val)
+}
+
+func (c Column) Sub(val any) MathExpr {
+	return mathOf(c, opSub, val)
+}
+
+func (c Column) Multiply(val any) MathExpr {
+	return mathOf(c, opMultiply, val)
+}
+
+func (c Column) Divide(val any) MathExpr {
+	return mathOf(c, opDivide, val)
+}
+
+func (c Column) binary(o op, arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    o,
+		right: valueOf(arg),
+	}
+}
+
+func inValuesOf(vals []any) Expression {
+	if len(vals) == 1 {
+		if sub, ok := vals[0].(Subquery); ok {
+			return sub
+		}
+	}
+	return values(vals)
+}
+
 func valueOf(arg any) Expression {
 	switch val := arg.(type) {
 	case Expression:
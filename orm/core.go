@@ -2,6 +2,7 @@ package orm
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/jackycsl/geektime-go-practical/orm/internal/valuer"
 	"github.com/jackycsl/geektime-go-practical/orm/model"
@@ -16,6 +17,8 @@ type core struct {
 }
 
 func get[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	qc.Tx = txOf(ctx, sess)
+	qc.ResultType = reflect.TypeOf((*T)(nil))
 	var root Handler = func(ctx context.Context, qc *QueryContext) *QueryResult {
 		return getHandler[T](ctx, sess, c, qc)
 	}
@@ -42,9 +45,17 @@ func getHandler[T any](ctx context.Context, sess Session, c core, qc *QueryConte
 			Err: err,
 		}
 	}
+	defer func() {
+		_ = rows.Close()
+	}()
 
 	// 你要确认有没有数据
 	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return &QueryResult{
+				Err: err,
+			}
+		}
 		// 要不要返回 error？
 		// 返回 error，和 sql 包语义保持一致
 		return &QueryResult{
@@ -61,6 +72,9 @@ func getHandler[T any](ctx context.Context, sess Session, c core, qc *QueryConte
 	tp := new(T)
 	val := c.creator(c.model, tp)
 	err = val.SetColumns(rows)
+	if err == nil {
+		err = afterFind(ctx, qc, tp)
+	}
 
 	// 接口定义好之后，就两件事，一个是用新接口的方法改造上层，
 	// 一个就是提供不同的实现
@@ -70,7 +84,97 @@ func getHandler[T any](ctx context.Context, sess Session, c core, qc *QueryConte
 	}
 }
 
+func getMulti[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	qc.Tx = txOf(ctx, sess)
+	qc.ResultType = reflect.TypeOf([]*T(nil))
+	var root Handler = func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getMultiHandler[T](ctx, sess, c, qc)
+	}
+	for i := len(c.mdls) - 1; i >= 0; i-- {
+		root = c.mdls[i](root)
+	}
+	return root(ctx, qc)
+}
+
+func getMultiHandler[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	q, err := qc.Builder.Build()
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+
+	rows, err := sess.queryContext(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	// 和 sql 包保持一致，没有数据的时候返回空切片，而不是 ErrNoRows
+	res := make([]*T, 0, 8)
+	for rows.Next() {
+		tp := new(T)
+		val := c.creator(c.model, tp)
+		if err = val.SetColumns(rows); err != nil {
+			return &QueryResult{
+				Err: err,
+			}
+		}
+		if err = afterFind(ctx, qc, tp); err != nil {
+			return &QueryResult{
+				Err: err,
+			}
+		}
+		res = append(res, tp)
+	}
+	// 遍历过程中可能出错，例如网络中断
+	if err = rows.Err(); err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	return &QueryResult{
+		Result: res,
+	}
+}
+
+func iter(ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	qc.Tx = txOf(ctx, sess)
+	var root Handler = func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return iterHandler(ctx, sess, qc)
+	}
+	for i := len(c.mdls) - 1; i >= 0; i-- {
+		root = c.mdls[i](root)
+	}
+	return root(ctx, qc)
+}
+
+// iterHandler 只发起查询，不处理结果集
+// 结果集 *sql.Rows 交给 Iterator 逐行读取，并且由 Iterator 负责关闭
+func iterHandler(ctx context.Context, sess Session, qc *QueryContext) *QueryResult {
+	q, err := qc.Builder.Build()
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	rows, err := sess.queryContext(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	return &QueryResult{
+		Result: rows,
+	}
+}
+
 func exec(ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	qc.Tx = txOf(ctx, sess)
 	var root Handler = func(ctx context.Context, qc *QueryContext) *QueryResult {
 		return execHandler(ctx, sess, c, qc)
 	}
@@ -16,6 +16,8 @@ type DBOption func(db *DB)
 type DB struct {
 	core
 	db *sql.DB
+	// stmts 为 nil 的时候不缓存 Stmt
+	stmts *stmtCache
 }
 
 func Open(driver string, dataSourceName string, opts ...DBOption) (*DB, error) {
@@ -50,7 +52,7 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{tx: tx}, nil
+	return &Tx{tx: tx, db: db, ctx: ctx}, nil
 }
 
 type txKey struct{}
@@ -82,13 +84,60 @@ func (db *DB) BeginTxV2(ctx context.Context, opts *sql.TxOptions) (context.Conte
 // 	return nil, errors.New("没有开事务")
 // }
 
+// DoTx 使用 PropagationRequired，ctx 里面已经有事务的时候直接加入
 func (db *DB) DoTx(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	return db.DoTxWithPropagation(ctx, PropagationRequired, fn, opts)
+}
+
+// DoTxWithPropagation 事务放在 ctx 里面传递，fn 拿到的 ctx 里面就是 tx
+// 所以 fn 里面再调用 DoTx 就可以根据 propagation 决定怎么使用外层的事务
+// 加入外层事务的时候，opts 会被忽略
+func (db *DB) DoTxWithPropagation(ctx context.Context, propagation Propagation,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions) error {
+	tx, ok := db.txFrom(ctx)
+	switch propagation {
+	case PropagationRequired:
+		if ok {
+			return fn(ctx, tx)
+		}
+	case PropagationNested:
+		if ok {
+			return tx.doSavepoint(ctx, fn)
+		}
+	case PropagationNever:
+		if ok {
+			return errs.ErrTxExists
+		}
+		return fn(ctx, nil)
+	case PropagationRequiresNew:
+	default:
+		return errs.NewErrUnsupportedPropagation(propagation)
+	}
+	return db.doTx(ctx, fn, opts)
+}
+
+// txFrom 取出 ctx 里面还没有结束的事务
+// 别的 DB 开启的事务不算
+func (db *DB) txFrom(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Tx)
+	if !ok || tx.done || tx.db != db {
+		return nil, false
+	}
+	return tx, true
+}
+
+// doTx 开启一个新的事务
+func (db *DB) doTx(ctx context.Context,
 	fn func(ctx context.Context, tx *Tx) error,
 	opts *sql.TxOptions) (err error) {
 	tx, err := db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
+	ctx = context.WithValue(ctx, txKey{}, tx)
 	panicked := true
 	defer func() {
 		if panicked || err != nil {
@@ -103,12 +152,48 @@ func (db *DB) DoTx(ctx context.Context,
 	return err
 }
 
-func (db *DB) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return db.db.QueryContext(ctx, query, args...)
+func (db *DB) queryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	// 驱动的错误翻译成 errs 里面的错误
+	defer func() {
+		err = db.dialect.translateErr(err)
+	}()
+	if db.stmts == nil {
+		return db.db.QueryContext(ctx, query, args...)
+	}
+	stmt, release, err := db.stmts.get(ctx, db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (db *DB) execContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	defer func() {
+		err = db.dialect.translateErr(err)
+	}()
+	if db.stmts == nil {
+		return db.db.ExecContext(ctx, query, args...)
+	}
+	stmt, release, err := db.stmts.get(ctx, db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.ExecContext(ctx, args...)
 }
 
-func (db *DB) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return db.db.ExecContext(ctx, query, args...)
+// Close 关闭缓存的 Stmt 和数据库连接
+// 关闭 Stmt 失败也要关闭连接池，返回第一个错误
+func (db *DB) Close() error {
+	var err error
+	if db.stmts != nil {
+		err = db.stmts.close()
+	}
+	if e := db.db.Close(); err == nil {
+		err = e
+	}
+	return err
 }
 
 func DBWithDialect(dialect Dialect) DBOption {
@@ -129,6 +214,16 @@ func DBWithRegistry(r model.Registry) DBOption {
 	}
 }
 
+// DBWithStmtCache 缓存最近使用的 size 个 Stmt，同样的 SQL 只需要 Prepare 一次
+// 事务里面通过 Tx.StmtContext 复用缓存的 Stmt
+func DBWithStmtCache(size int) DBOption {
+	return func(db *DB) {
+		if size > 0 {
+			db.stmts = newStmtCache(size)
+		}
+	}
+}
+
 func DBUseReflect() DBOption {
 	return func(db *DB) {
 		db.creator = valuer.NewReflectValue
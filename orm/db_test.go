@@ -1,8 +1,242 @@
 package orm
 
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 // func TestDB_DoTx(t *testing.T) {
 // 	db := memoryDB(t)
 // 	err := db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
 // 		// 在这里执行业务逻辑，使用 tx
 // 	}, &sql.TxOptions{})
 // }
+
+func TestDB_DoTxWithPropagation(t *testing.T) {
+	bizErr := errors.New("biz error")
+	testCases := []struct {
+		name        string
+		propagation Propagation
+		// inner 外层事务里面执行的 fn
+		inner func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error
+		mock  func(mock sqlmock.Sqlmock)
+
+		wantErr      error
+		wantInnerErr error
+	}{
+		{
+			name:        "required",
+			propagation: PropagationRequired,
+			inner: func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error {
+				return func(ctx context.Context, tx *Tx) error {
+					assert.Same(t, outer, tx)
+					return nil
+				}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+		},
+		{
+			// 加入外层事务，错误交给外层处理
+			name:        "required error",
+			propagation: PropagationRequired,
+			inner: func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error {
+				return func(ctx context.Context, tx *Tx) error {
+					return bizErr
+				}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			wantErr:      bizErr,
+			wantInnerErr: bizErr,
+		},
+		{
+			name:        "requires new",
+			propagation: PropagationRequiresNew,
+			inner: func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error {
+				return func(ctx context.Context, tx *Tx) error {
+					assert.NotSame(t, outer, tx)
+					return nil
+				}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:        "nested",
+			propagation: PropagationNested,
+			inner: func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error {
+				return func(ctx context.Context, tx *Tx) error {
+					assert.Same(t, outer, tx)
+					return nil
+				}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT sp_1")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("RELEASE SAVEPOINT sp_1")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			// 只回滚到 SAVEPOINT，外层事务依旧提交
+			name:        "nested error",
+			propagation: PropagationNested,
+			inner: func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error {
+				return func(ctx context.Context, tx *Tx) error {
+					return bizErr
+				}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT sp_1")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT sp_1")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantInnerErr: bizErr,
+		},
+		{
+			name:        "never",
+			propagation: PropagationNever,
+			inner: func(t *testing.T, outer *Tx) func(ctx context.Context, tx *Tx) error {
+				return func(ctx context.Context, tx *Tx) error {
+					t.Fatal("不应该执行")
+					return nil
+				}
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			wantErr:      errs.ErrTxExists,
+			wantInnerErr: errs.ErrTxExists,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			var innerErr error
+			err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+				innerErr = db.DoTxWithPropagation(ctx, tc.propagation, tc.inner(t, tx), nil)
+				if tc.propagation == PropagationNested {
+					// 内层的错误已经处理过了，外层继续
+					return nil
+				}
+				return innerErr
+			}, nil)
+			assert.True(t, errors.Is(err, tc.wantErr))
+			assert.True(t, errors.Is(innerErr, tc.wantInnerErr))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_DoTxWithPropagation_NoTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// 没有外层事务的时候，Nested 开启新事务
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	err = db.DoTxWithPropagation(context.Background(), PropagationNested,
+		func(ctx context.Context, tx *Tx) error {
+			assert.NotNil(t, tx)
+			return nil
+		}, nil)
+	require.NoError(t, err)
+
+	err = db.DoTxWithPropagation(context.Background(), PropagationNever,
+		func(ctx context.Context, tx *Tx) error {
+			assert.Nil(t, tx)
+			return nil
+		}, nil)
+	require.NoError(t, err)
+
+	err = db.DoTxWithPropagation(context.Background(), Propagation(100),
+		func(ctx context.Context, tx *Tx) error {
+			return nil
+		}, nil)
+	assert.Equal(t, errs.NewErrUnsupportedPropagation(Propagation(100)), err)
+
+	// 已经提交的事务不会被复用
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	var ctx context.Context
+	err = db.DoTx(context.Background(), func(c context.Context, tx *Tx) error {
+		ctx = c
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_DoTxWithPropagation_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:savepoint.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.db.Close()
+	_, err = db.db.Exec("CREATE TABLE savepoint_model(id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	bizErr := errors.New("biz error")
+	ctx := context.Background()
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		if err := NewInserter[SavepointModel](tx).Values(&SavepointModel{Id: 1}).Exec(ctx).Err(); err != nil {
+			return err
+		}
+		err := db.DoTxWithPropagation(ctx, PropagationNested, func(ctx context.Context, tx *Tx) error {
+			if err := NewInserter[SavepointModel](tx).Values(&SavepointModel{Id: 2}).Exec(ctx).Err(); err != nil {
+				return err
+			}
+			return bizErr
+		}, nil)
+		assert.True(t, errors.Is(err, bizErr))
+		return db.DoTxWithPropagation(ctx, PropagationNested, func(ctx context.Context, tx *Tx) error {
+			return NewInserter[SavepointModel](tx).Values(&SavepointModel{Id: 3}).Exec(ctx).Err()
+		}, nil)
+	}, nil)
+	require.NoError(t, err)
+
+	res, err := NewSelector[SavepointModel](db).OrderBy(Asc("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*SavepointModel{{Id: 1}, {Id: 3}}, res)
+}
+
+type SavepointModel struct {
+	Id int64
+}
@@ -0,0 +1,195 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/jackycsl/geektime-go-practical/orm/model"
+)
+
+// columnKind 把 Go 类型归类，不同的方言再映射到具体的列类型
+type columnKind int
+
+const (
+	kindUnknown columnKind = iota
+	kindBool
+	kindSmallInt
+	kindInt
+	kindBigInt
+	kindFloat
+	kindDouble
+	kindString
+	kindBytes
+	kindTime
+	// kindText 其它实现了 driver.Valuer 的类型，例如 JsonColumn
+	kindText
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	bytesType  = reflect.TypeOf([]byte(nil))
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	// nullTypes sql.NullXXX 对应的类型
+	nullTypes = map[reflect.Type]columnKind{
+		reflect.TypeOf(sql.NullBool{}):    kindBool,
+		reflect.TypeOf(sql.NullByte{}):    kindSmallInt,
+		reflect.TypeOf(sql.NullInt16{}):   kindSmallInt,
+		reflect.TypeOf(sql.NullInt32{}):   kindInt,
+		reflect.TypeOf(sql.NullInt64{}):   kindBigInt,
+		reflect.TypeOf(sql.NullFloat64{}): kindDouble,
+		reflect.TypeOf(sql.NullString{}):  kindString,
+		reflect.TypeOf(sql.NullTime{}):    kindTime,
+	}
+)
+
+// kindOf 返回列的类型，以及这个类型本身能不能表达 NULL
+// 指针、sql.NullXXX 和其它实现了 driver.Valuer 的类型都认为可以是 NULL
+func kindOf(typ reflect.Type) (columnKind, bool) {
+	nullable := false
+	if typ.Kind() == reflect.Pointer {
+		typ, nullable = typ.Elem(), true
+	}
+	if kind, ok := nullTypes[typ]; ok {
+		return kind, true
+	}
+	switch {
+	case typ == timeType:
+		return kindTime, nullable
+	case typ == bytesType:
+		return kindBytes, true
+	case typ.Implements(valuerType) || reflect.PointerTo(typ).Implements(valuerType):
+		return kindText, true
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return kindBool, nullable
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return kindSmallInt, nullable
+	case reflect.Int32, reflect.Uint16:
+		return kindInt, nullable
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return kindBigInt, nullable
+	case reflect.Float32:
+		return kindFloat, nullable
+	case reflect.Float64:
+		return kindDouble, nullable
+	case reflect.String:
+		return kindString, nullable
+	}
+	return kindUnknown, nullable
+}
+
+// columnTypes 是方言里面类型的映射
+// 字符串比较特殊，有 size 的时候用 VARCHAR(size)，没有的时候用 string 对应的类型
+type columnTypes map[columnKind]string
+
+func (c columnTypes) columnType(fd *model.Field) (string, error) {
+	kind, _ := kindOf(fd.Type)
+	if kind == kindString && fd.Size > 0 {
+		return "VARCHAR(" + strconv.Itoa(fd.Size) + ")", nil
+	}
+	typ, ok := c[kind]
+	if !ok {
+		return "", errs.NewErrUnsupportedColumnType(fd.GoName, fd.Type)
+	}
+	return typ, nil
+}
+
+// buildCreateTable 构造建表语句，索引需要单独创建
+func (b *builder) buildCreateTable(m *model.Model) (string, error) {
+	b.reset()
+	b.sb.WriteString("CREATE TABLE ")
+	b.quote(m.TableName)
+	b.sb.WriteString(" (")
+	inlinePK := false
+	for i, fd := range m.Fields {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		inline, err := b.buildColumnDef(fd)
+		if err != nil {
+			return "", err
+		}
+		inlinePK = inlinePK || inline
+	}
+	if len(m.PrimaryKeys) > 0 && !inlinePK {
+		b.sb.WriteString(",PRIMARY KEY (")
+		for i, fd := range m.PrimaryKeys {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.quote(fd.ColName)
+		}
+		b.sb.WriteByte(')')
+	}
+	b.sb.WriteString(");")
+	return b.sb.String(), nil
+}
+
+// buildColumnDef 构造列定义，例如 `id` BIGINT NOT NULL AUTO_INCREMENT
+// 返回值代表主键是不是已经在列定义里面声明了
+func (b *builder) buildColumnDef(fd *model.Field) (bool, error) {
+	typ, err := b.dialect.columnType(fd)
+	if err != nil {
+		return false, err
+	}
+	b.quote(fd.ColName)
+	b.sb.WriteByte(' ')
+	b.sb.WriteString(typ)
+	_, nullable := kindOf(fd.Type)
+	if fd.PrimaryKey || !(fd.Nullable || nullable) {
+		b.sb.WriteString(" NOT NULL")
+	}
+	if fd.Default != "" {
+		b.sb.WriteString(" DEFAULT ")
+		b.sb.WriteString(fd.Default)
+	}
+	if !fd.AutoIncrement {
+		return false, nil
+	}
+	suffix, inlinePK := b.dialect.autoIncrement()
+	b.sb.WriteString(suffix)
+	return inlinePK, nil
+}
+
+// buildCreateIndex 构造建索引语句
+// MySQL 不支持 CREATE INDEX IF NOT EXISTS，所以要不要创建由调用者决定
+func (b *builder) buildCreateIndex(m *model.Model, idx *model.Index) string {
+	b.reset()
+	b.sb.WriteString("CREATE ")
+	if idx.Unique {
+		b.sb.WriteString("UNIQUE ")
+	}
+	b.sb.WriteString("INDEX ")
+	b.quote(idx.Name)
+	b.sb.WriteString(" ON ")
+	b.quote(m.TableName)
+	b.sb.WriteString(" (")
+	for i, fd := range idx.Fields {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(fd.ColName)
+	}
+	b.sb.WriteString(");")
+	return b.sb.String()
+}
+
+// buildAddColumn 构造加列的语句
+// 给已经有数据的表加 NOT NULL 的列，需要在标签里面指定默认值
+func (b *builder) buildAddColumn(m *model.Model, fd *model.Field) (string, error) {
+	b.reset()
+	b.sb.WriteString("ALTER TABLE ")
+	b.quote(m.TableName)
+	b.sb.WriteString(" ADD COLUMN ")
+	if _, err := b.buildColumnDef(fd); err != nil {
+		return "", err
+	}
+	b.sb.WriteByte(';')
+	return b.sb.String(), nil
+}
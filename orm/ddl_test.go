@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrator_CreateTable(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		entity  any
+
+		wantErr  error
+		wantStmt []string
+	}{
+		{
+			name:    "mysql",
+			dialect: DialectMySQL,
+			entity:  &SchemaModel{},
+			wantStmt: []string{
+				"CREATE TABLE `schema_model` (`id` BIGINT NOT NULL AUTO_INCREMENT," +
+					"`name` VARCHAR(64) NOT NULL DEFAULT ''," +
+					"`email` VARCHAR(255) NOT NULL,`age` SMALLINT,`score` DOUBLE NOT NULL," +
+					"`nickname` VARCHAR(255),`avatar` BLOB,`deleted_at` DATETIME," +
+					"`created_at` DATETIME NOT NULL,`tenant_id` INT NOT NULL,`settings` TEXT," +
+					"`active` BOOLEAN NOT NULL,PRIMARY KEY (`id`));",
+				"CREATE INDEX `idx_tenant_name` ON `schema_model` (`name`,`tenant_id`);",
+				"CREATE UNIQUE INDEX `uk_schema_model_email` ON `schema_model` (`email`);",
+				"CREATE INDEX `idx_schema_model_deleted_at` ON `schema_model` (`deleted_at`);",
+			},
+		},
+		{
+			name:    "sqlite",
+			dialect: DialectSQLite,
+			entity:  &SchemaModel{},
+			wantStmt: []string{
+				"CREATE TABLE `schema_model` (`id` INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT," +
+					"`name` VARCHAR(64) NOT NULL DEFAULT ''," +
+					"`email` TEXT NOT NULL,`age` INTEGER,`score` REAL NOT NULL," +
+					"`nickname` TEXT,`avatar` BLOB,`deleted_at` DATETIME," +
+					"`created_at` DATETIME NOT NULL,`tenant_id` INTEGER NOT NULL,`settings` TEXT," +
+					"`active` BOOLEAN NOT NULL);",
+				"CREATE INDEX `idx_tenant_name` ON `schema_model` (`name`,`tenant_id`);",
+				"CREATE UNIQUE INDEX `uk_schema_model_email` ON `schema_model` (`email`);",
+				"CREATE INDEX `idx_schema_model_deleted_at` ON `schema_model` (`deleted_at`);",
+			},
+		},
+		{
+			name:    "postgresql",
+			dialect: DialectPostgreSQL,
+			entity:  &SchemaModel{},
+			wantStmt: []string{
+				`CREATE TABLE "schema_model" ("id" BIGSERIAL NOT NULL,` +
+					`"name" VARCHAR(64) NOT NULL DEFAULT '',` +
+					`"email" TEXT NOT NULL,"age" SMALLINT,"score" DOUBLE PRECISION NOT NULL,` +
+					`"nickname" TEXT,"avatar" BYTEA,"deleted_at" TIMESTAMP,` +
+					`"created_at" TIMESTAMP NOT NULL,"tenant_id" INTEGER NOT NULL,"settings" TEXT,` +
+					`"active" BOOLEAN NOT NULL,PRIMARY KEY ("id"));`,
+				`CREATE INDEX "idx_tenant_name" ON "schema_model" ("name","tenant_id");`,
+				`CREATE UNIQUE INDEX "uk_schema_model_email" ON "schema_model" ("email");`,
+				`CREATE INDEX "idx_schema_model_deleted_at" ON "schema_model" ("deleted_at");`,
+			},
+		},
+		{
+			name:    "composite primary key",
+			dialect: DialectSQLite,
+			entity: func() any {
+				type UserRole struct {
+					UserId int64 `orm:"pk"`
+					RoleId int64 `orm:"pk"`
+				}
+				return &UserRole{}
+			}(),
+			wantStmt: []string{
+				"CREATE TABLE `user_role` (`user_id` INTEGER NOT NULL,`role_id` INTEGER NOT NULL," +
+					"PRIMARY KEY (`user_id`,`role_id`));",
+			},
+		},
+		{
+			name:    "unsupported type",
+			dialect: DialectMySQL,
+			entity: func() any {
+				type Unsupported struct {
+					Tags map[string]string
+				}
+				return &Unsupported{}
+			}(),
+			wantErr: errs.NewErrUnsupportedColumnType("Tags", reflect.TypeOf(map[string]string{})),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			stmts, err := NewMigrator(db).CreateTable(tc.entity)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantStmt, stmts)
+		})
+	}
+}
+
+type SchemaModel struct {
+	Id        int64  `orm:"pk,auto_increment"`
+	Name      string `orm:"size=64,default='',index=idx_tenant_name"`
+	Email     string `orm:"unique"`
+	Age       *int8
+	Score     float64
+	Nickname  sql.NullString
+	Avatar    []byte
+	DeletedAt *time.Time `orm:"index"`
+	CreatedAt time.Time
+	TenantId  int32 `orm:"index=idx_tenant_name"`
+	Settings  JsonColumn[Settings]
+	Active    bool
+}
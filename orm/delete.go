@@ -0,0 +1,145 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/jackycsl/geektime-go-practical/orm/model"
+)
+
+// Deleter 用于构造 DELETE 语句
+type Deleter[T any] struct {
+	builder
+	sess  Session
+	table TableReference
+	where []Predicate
+	// unscoped 为 true 的时候，即便有软删除列也执行 DELETE
+	unscoped bool
+}
+
+func NewDeleter[T any](sess Session) *Deleter[T] {
+	c := sess.getCore()
+	return &Deleter[T]{
+		builder: builder{
+			core:   c,
+			quoter: c.dialect.quoter(),
+		},
+		sess: sess,
+	}
+}
+
+// From 指定表，如果没有调用，那么就使用 T 对应的表
+// DELETE 只支持单表，所以只能传入 Table
+func (d *Deleter[T]) From(table TableReference) *Deleter[T] {
+	d.table = table
+	return d
+}
+
+func (d *Deleter[T]) Where(ps ...Predicate) *Deleter[T] {
+	d.where = ps
+	return d
+}
+
+// Unscoped 忽略软删除，直接 DELETE
+func (d *Deleter[T]) Unscoped() *Deleter[T] {
+	d.unscoped = true
+	return d
+}
+
+func (d *Deleter[T]) Build() (*Query, error) {
+	if d.model == nil {
+		var err error
+		d.model, err = d.r.Get(new(T))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch d.table.(type) {
+	case nil, Table:
+	default:
+		return nil, errs.NewErrUnsupportedTable(d.table)
+	}
+	m, err := d.tableModel(d.table)
+	if err != nil {
+		return nil, err
+	}
+
+	d.reset()
+	where := d.where
+	if d.softDelete(m) {
+		// 软删除，已经删除的数据不需要再更新一次
+		d.sb.WriteString("UPDATE ")
+		d.quote(m.TableName)
+		d.sb.WriteString(" SET ")
+		d.quote(m.SoftDelete.ColName)
+		d.sb.WriteByte('=')
+		d.sb.WriteString(d.dialect.now())
+		// DELETE 不支持别名，所以这里不能带上别名
+		var tbl TableReference
+		if t, ok := d.table.(Table); ok {
+			tbl = TableOf(t.entity)
+		}
+		p := Column{table: tbl, name: m.SoftDelete.GoName}.IsNull()
+		where = append(where[:len(where):len(where)], p)
+	} else {
+		d.sb.WriteString("DELETE FROM ")
+		d.quote(m.TableName)
+	}
+
+	if len(where) > 0 {
+		d.sb.WriteString(" WHERE ")
+		if err := d.buildPredicates(where); err != nil {
+			return nil, err
+		}
+	}
+
+	d.sb.WriteByte(';')
+	return &Query{
+		SQL:  d.sb.String(),
+		Args: d.args,
+	}, nil
+}
+
+func (d *Deleter[T]) Exec(ctx context.Context) Result {
+	var err error
+	d.model, err = d.r.Get(new(T))
+	if err != nil {
+		return Result{
+			err: err,
+		}
+	}
+
+	// 软删除实际上是 UPDATE 语句
+	typ := "DELETE"
+	m, err := d.tableModel(d.table)
+	if err != nil {
+		return Result{
+			err: err,
+		}
+	}
+	if d.softDelete(m) {
+		typ = "UPDATE"
+	}
+	res := exec(ctx, d.sess, d.core, &QueryContext{
+		Type:     typ,
+		Builder:  d,
+		Model:    d.model,
+		HasWhere: len(d.where) > 0,
+		// From 指定的表才是被修改的表
+		Tables: []string{m.TableName},
+	})
+	var sqlRes sql.Result
+	if res.Result != nil {
+		sqlRes = res.Result.(sql.Result)
+	}
+	return Result{
+		err: res.Err,
+		res: sqlRes,
+	}
+}
+
+func (d *Deleter[T]) softDelete(m *model.Model) bool {
+	return m.SoftDelete != nil && !d.unscoped
+}
@@ -0,0 +1,120 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleter_Build(t *testing.T) {
+	db := memoryDB(t)
+	join := TableOf(&TestModel{}).Join(TableOf(&TestModel{})).Using("Id")
+	testCases := []struct {
+		name string
+		d    QueryBuilder
+
+		wantErr   error
+		wantQuery *Query
+	}{
+		{
+			name: "no where",
+			d:    NewDeleter[TestModel](db),
+			wantQuery: &Query{
+				SQL: "DELETE FROM `test_model`;",
+			},
+		},
+		{
+			name: "where",
+			d:    NewDeleter[TestModel](db).Where(C("Id").Eq(16)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE `id` = ?;",
+				Args: []any{16},
+			},
+		},
+		{
+			name: "from",
+			d: NewDeleter[TestModel](db).From(TableOf(&TestModel{})).
+				Where(C("Id").Eq(16).And(C("Age").Eq(18))),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `test_model` WHERE (`id` = ?) AND (`age` = ?);",
+				Args: []any{16, 18},
+			},
+		},
+		{
+			name:    "invalid column",
+			d:       NewDeleter[TestModel](db).Where(C("Invalid").Eq(16)),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name:    "unsupported table",
+			d:       NewDeleter[TestModel](db).From(join),
+			wantErr: errs.NewErrUnsupportedTable(join),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.d.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestDeleter_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	testCases := []struct {
+		name     string
+		d        *Deleter[TestModel]
+		wantErr  error
+		affected int64
+	}{
+		{
+			name:    "query error",
+			d:       NewDeleter[TestModel](db).Where(C("Invalid").Eq(1)),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name: "db error",
+			d: func() *Deleter[TestModel] {
+				mock.ExpectExec("DELETE FROM .*").
+					WillReturnError(errors.New("db error"))
+				return NewDeleter[TestModel](db).Where(C("Id").Eq(1))
+			}(),
+			wantErr: errors.New("db error"),
+		},
+		{
+			name: "exec",
+			d: func() *Deleter[TestModel] {
+				res := driver.RowsAffected(1)
+				mock.ExpectExec("DELETE FROM .*").
+					WillReturnResult(res)
+				return NewDeleter[TestModel](db).Where(C("Id").Eq(1))
+			}(),
+			affected: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := tc.d.Exec(context.Background())
+			affected, err := res.RowsAffected()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.affected, affected)
+		})
+	}
+}
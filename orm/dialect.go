@@ -1,7 +1,10 @@
 package orm
 
 import (
+	"strconv"
+
 	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/jackycsl/geektime-go-practical/orm/model"
 )
 
 var (
@@ -15,18 +18,136 @@ type Dialect interface {
 	// MySQL `
 	quoter() byte
 
+	// placeholder 返回第 index 个参数的占位符，index 从 1 开始
+	// MySQL 和 SQLite 都是 ?，PostgreSQL 是 $1, $2
+	placeholder(index int) string
+
+	// now 当前时间的函数，用于软删除
+	now() string
+
+	// noLimit 只有 OFFSET 的时候，LIMIT 后面跟着的代表不限制行数的值
+	// 空字符串代表可以不写 LIMIT
+	noLimit() string
+
+	// columnType 返回字段在建表语句里面的类型
+	columnType(fd *model.Field) (string, error)
+	// autoIncrement 返回自增列定义的后缀
+	// 第二个返回值代表后缀里面已经包含了主键的声明，例如 SQLite
+	autoIncrement() (string, bool)
+	// columnsSQL 查询表里面所有列名的语句，参数是表名
+	columnsSQL() string
+	// indexesSQL 查询表里面所有索引名的语句，参数是表名
+	indexesSQL() string
+
 	buildUpsert(b *builder, upsert *Upsert) error
+
+	// translateErr 根据驱动的错误码，把错误归类为 errs 里面的错误，例如 ErrDuplicateKey
+	// 不认识的错误原样返回
+	translateErr(err error) error
 }
 
 type standardSQL struct {
 }
 
+// quoter SQL 标准用的是双引号
 func (s standardSQL) quoter() byte {
-	panic("not implemented") // TODO: Implement
+	return '"'
+}
+
+func (s standardSQL) placeholder(index int) string {
+	return "?"
+}
+
+// now SQLite 没有 NOW()，所以标准里面用 CURRENT_TIMESTAMP
+func (s standardSQL) now() string {
+	return "CURRENT_TIMESTAMP"
+}
+
+// noLimit SQL 标准里面 OFFSET 可以单独使用
+func (s standardSQL) noLimit() string {
+	return ""
+}
+
+var standardColumnTypes = columnTypes{
+	kindBool:     "BOOLEAN",
+	kindSmallInt: "SMALLINT",
+	kindInt:      "INTEGER",
+	kindBigInt:   "BIGINT",
+	kindFloat:    "REAL",
+	kindDouble:   "DOUBLE PRECISION",
+	kindString:   "VARCHAR(255)",
+	kindBytes:    "BLOB",
+	kindTime:     "TIMESTAMP",
+	kindText:     "TEXT",
 }
 
-func (standardSQL) buildUpsert(b *builder, upsert *Upsert) error {
-	panic("not implemented") // TODO: Implement
+func (s standardSQL) columnType(fd *model.Field) (string, error) {
+	return standardColumnTypes.columnType(fd)
+}
+
+func (s standardSQL) autoIncrement() (string, bool) {
+	return " GENERATED BY DEFAULT AS IDENTITY", false
+}
+
+// columnsSQL information_schema 是 SQL 标准里面的
+func (s standardSQL) columnsSQL() string {
+	return "SELECT column_name FROM information_schema.columns " +
+		"WHERE table_schema = current_schema() AND table_name = ?;"
+}
+
+// indexesSQL 标准里面没有索引，这里和 PostgreSQL 保持一致
+func (s standardSQL) indexesSQL() string {
+	return "SELECT indexname FROM pg_indexes " +
+		"WHERE schemaname = current_schema() AND tablename = ?;"
+}
+
+// buildUpsert SQL 标准里面没有 upsert，这里用的是 ON CONFLICT 的写法
+// 冲突列是必须的
+func (s standardSQL) buildUpsert(b *builder, upsert *Upsert) error {
+	if len(upsert.conflictColumns) == 0 {
+		return errs.ErrNoConflictColumns
+	}
+	b.sb.WriteString(" ON CONFLICT(")
+	for i, col := range upsert.conflictColumns {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		err := b.buildColumn(Column{name: col})
+		if err != nil {
+			return err
+		}
+	}
+	b.sb.WriteString(") DO UPDATE SET ")
+	for idx, assign := range upsert.assigns {
+		if idx > 0 {
+			b.sb.WriteByte(',')
+		}
+		switch a := assign.(type) {
+		case Assignment:
+			fd, ok := b.model.FieldMap[a.col]
+			// 字段不对，或者说列不对
+			if !ok {
+				return errs.NewErrUnknownField(a.col)
+			}
+			b.quote(fd.ColName)
+			b.sb.WriteByte('=')
+			if err := b.buildExpression(valueOf(a.val)); err != nil {
+				return err
+			}
+		case Column:
+			fd, ok := b.model.FieldMap[a.name]
+			// 字段不对，或者说列不对
+			if !ok {
+				return errs.NewErrUnknownField(a.name)
+			}
+			b.quote(fd.ColName)
+			b.sb.WriteString("=EXCLUDED.")
+			b.quote(fd.ColName)
+		default:
+			return errs.NewErrUnsupportedAssignable(assign)
+		}
+	}
+	return nil
 }
 
 type mysqlDialect struct {
@@ -37,6 +158,47 @@ func (s mysqlDialect) quoter() byte {
 	return '`'
 }
 
+func (s mysqlDialect) now() string {
+	return "NOW()"
+}
+
+// noLimit MySQL 必须带上 LIMIT，官方文档建议用 BIGINT UNSIGNED 的最大值
+func (s mysqlDialect) noLimit() string {
+	return "18446744073709551615"
+}
+
+var mysqlColumnTypes = columnTypes{
+	kindBool:     "BOOLEAN",
+	kindSmallInt: "SMALLINT",
+	kindInt:      "INT",
+	kindBigInt:   "BIGINT",
+	kindFloat:    "FLOAT",
+	kindDouble:   "DOUBLE",
+	kindString:   "VARCHAR(255)",
+	kindBytes:    "BLOB",
+	kindTime:     "DATETIME",
+	kindText:     "TEXT",
+}
+
+func (s mysqlDialect) columnType(fd *model.Field) (string, error) {
+	return mysqlColumnTypes.columnType(fd)
+}
+
+func (s mysqlDialect) autoIncrement() (string, bool) {
+	return " AUTO_INCREMENT", false
+}
+
+func (s mysqlDialect) columnsSQL() string {
+	return "SELECT COLUMN_NAME FROM information_schema.COLUMNS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;"
+}
+
+// indexesSQL 主键的名字是 PRIMARY
+func (s mysqlDialect) indexesSQL() string {
+	return "SELECT DISTINCT INDEX_NAME FROM information_schema.STATISTICS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;"
+}
+
 func (s mysqlDialect) buildUpsert(b *builder, upsert *Upsert) error {
 	b.sb.WriteString(" ON DUPLICATE KEY UPDATE ")
 	for idx, assign := range upsert.assigns {
@@ -51,8 +213,10 @@ func (s mysqlDialect) buildUpsert(b *builder, upsert *Upsert) error {
 				return errs.NewErrUnknownField(a.col)
 			}
 			b.quote(fd.ColName)
-			b.sb.WriteString("=?")
-			b.addArg(a.val)
+			b.sb.WriteByte('=')
+			if err := b.buildExpression(valueOf(a.val)); err != nil {
+				return err
+			}
 		case Column:
 			fd, ok := b.model.FieldMap[a.name]
 			// 字段不对，或者说列不对
@@ -79,6 +243,42 @@ func (s sqliteDialect) quoter() byte {
 	return '`'
 }
 
+// SQLite 的类型只是亲和性，整数都用 INTEGER，这样自增主键才能生效
+var sqliteColumnTypes = columnTypes{
+	kindBool:     "BOOLEAN",
+	kindSmallInt: "INTEGER",
+	kindInt:      "INTEGER",
+	kindBigInt:   "INTEGER",
+	kindFloat:    "REAL",
+	kindDouble:   "REAL",
+	kindString:   "TEXT",
+	kindBytes:    "BLOB",
+	kindTime:     "DATETIME",
+	kindText:     "TEXT",
+}
+
+// noLimit SQLite 必须带上 LIMIT，负数代表不限制
+func (s sqliteDialect) noLimit() string {
+	return "-1"
+}
+
+func (s sqliteDialect) columnType(fd *model.Field) (string, error) {
+	return sqliteColumnTypes.columnType(fd)
+}
+
+// autoIncrement SQLite 的自增列必须是 INTEGER PRIMARY KEY
+func (s sqliteDialect) autoIncrement() (string, bool) {
+	return " PRIMARY KEY AUTOINCREMENT", true
+}
+
+func (s sqliteDialect) columnsSQL() string {
+	return "SELECT name FROM pragma_table_info(?);"
+}
+
+func (s sqliteDialect) indexesSQL() string {
+	return "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?;"
+}
+
 func (s sqliteDialect) buildUpsert(b *builder, upsert *Upsert) error {
 	b.sb.WriteString(" ON CONFLICT(")
 	for i, col := range upsert.conflictColumns {
@@ -103,8 +303,10 @@ func (s sqliteDialect) buildUpsert(b *builder, upsert *Upsert) error {
 				return errs.NewErrUnknownField(a.col)
 			}
 			b.quote(fd.ColName)
-			b.sb.WriteString("=?")
-			b.addArg(a.val)
+			b.sb.WriteByte('=')
+			if err := b.buildExpression(valueOf(a.val)); err != nil {
+				return err
+			}
 		case Column:
 			fd, ok := b.model.FieldMap[a.name]
 			// 字段不对，或者说列不对
@@ -122,6 +324,56 @@ func (s sqliteDialect) buildUpsert(b *builder, upsert *Upsert) error {
 
 }
 
+// postgreDialect 引号和 upsert 都和 SQL 标准一致
 type postgreDialect struct {
 	standardSQL
 }
+
+func (s postgreDialect) placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (s postgreDialect) now() string {
+	return "NOW()"
+}
+
+var postgreColumnTypes = columnTypes{
+	kindBool:     "BOOLEAN",
+	kindSmallInt: "SMALLINT",
+	kindInt:      "INTEGER",
+	kindBigInt:   "BIGINT",
+	kindFloat:    "REAL",
+	kindDouble:   "DOUBLE PRECISION",
+	kindString:   "TEXT",
+	kindBytes:    "BYTEA",
+	kindTime:     "TIMESTAMP",
+	kindText:     "TEXT",
+}
+
+// postgreSerialTypes 自增列用 SERIAL 系列的类型
+var postgreSerialTypes = columnTypes{
+	kindSmallInt: "SMALLSERIAL",
+	kindInt:      "SERIAL",
+	kindBigInt:   "BIGSERIAL",
+}
+
+func (s postgreDialect) columnType(fd *model.Field) (string, error) {
+	if fd.AutoIncrement {
+		return postgreSerialTypes.columnType(fd)
+	}
+	return postgreColumnTypes.columnType(fd)
+}
+
+func (s postgreDialect) autoIncrement() (string, bool) {
+	return "", false
+}
+
+func (s postgreDialect) columnsSQL() string {
+	return "SELECT column_name FROM information_schema.columns " +
+		"WHERE table_schema = current_schema() AND table_name = $1;"
+}
+
+func (s postgreDialect) indexesSQL() string {
+	return "SELECT indexname FROM pg_indexes " +
+		"WHERE schemaname = current_schema() AND tablename = $1;"
+}
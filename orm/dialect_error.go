@@ -0,0 +1,112 @@
+package orm
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+)
+
+// sqlStater PostgreSQL 的驱动都会实现，例如 pgx 的 *pgconn.PgError
+type sqlStater interface {
+	SQLState() string
+}
+
+// sqlStates SQL 标准的 SQLSTATE，再加上 PostgreSQL 特有的
+var sqlStates = map[string]error{
+	"23505": errs.ErrDuplicateKey,
+	"23503": errs.ErrForeignKeyViolation,
+	"40001": errs.ErrSerializationFailure,
+	"40P01": errs.ErrDeadlock,
+	"55P03": errs.ErrLockWaitTimeout,
+}
+
+func (s standardSQL) translateErr(err error) error {
+	var se sqlStater
+	if err == nil || !errors.As(err, &se) {
+		return err
+	}
+	if sentinel, ok := sqlStates[se.SQLState()]; ok {
+		return errs.NewErrDB(sentinel, err)
+	}
+	return err
+}
+
+var mysqlErrNumbers = map[int64]error{
+	// ER_DUP_ENTRY
+	1062: errs.ErrDuplicateKey,
+	// ER_ROW_IS_REFERENCED_2 删除或者更新被引用的行
+	1451: errs.ErrForeignKeyViolation,
+	// ER_NO_REFERENCED_ROW_2 引用的行不存在
+	1452: errs.ErrForeignKeyViolation,
+	// ER_LOCK_DEADLOCK
+	1213: errs.ErrDeadlock,
+	// ER_LOCK_WAIT_TIMEOUT
+	1205: errs.ErrLockWaitTimeout,
+}
+
+// translateErr 和 SQLite 一样用反射读取 *mysql.MySQLError 的 Number
+// 这样 orm 不需要依赖 MySQL 的驱动
+func (s mysqlDialect) translateErr(err error) error {
+	return translateDriverErr(err, "github.com/go-sql-driver/mysql", mysqlErrNumbers, "Number")
+}
+
+var sqliteErrCodes = map[int64]error{
+	// SQLITE_CONSTRAINT_UNIQUE
+	2067: errs.ErrDuplicateKey,
+	// SQLITE_CONSTRAINT_PRIMARYKEY
+	1555: errs.ErrDuplicateKey,
+	// SQLITE_CONSTRAINT_FOREIGNKEY
+	787: errs.ErrForeignKeyViolation,
+	// SQLITE_BUSY 超过了 busy_timeout 依旧拿不到锁
+	5: errs.ErrLockWaitTimeout,
+}
+
+// translateErr go-sqlite3 需要 cgo，为了不让 orm 依赖它，这里用反射读取错误码
+// 它的 Error 结构体有 Code 和 ExtendedCode 两个字段
+func (s sqliteDialect) translateErr(err error) error {
+	return translateDriverErr(err, "github.com/mattn/go-sqlite3", sqliteErrCodes, "ExtendedCode", "Code")
+}
+
+// translateDriverErr 在错误链里面找到 pkgPath 这个驱动包定义的错误
+// 然后按照顺序读取 fields 里面的错误码，找到第一个认识的错误码
+// 用反射是为了不让 orm 依赖具体的驱动，也不会因为导入驱动而注册驱动
+func translateDriverErr(err error, pkgPath string, codes map[int64]error, fields ...string) error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		val := reflect.ValueOf(e)
+		if val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				continue
+			}
+			val = val.Elem()
+		}
+		typ := val.Type()
+		if typ.Kind() != reflect.Struct || typ.PkgPath() != pkgPath {
+			continue
+		}
+		for _, name := range fields {
+			code, ok := intField(val, name)
+			if !ok {
+				continue
+			}
+			if sentinel, ok := codes[code]; ok {
+				return errs.NewErrDB(sentinel, err)
+			}
+		}
+		return err
+	}
+	return err
+}
+
+func intField(val reflect.Value, name string) (int64, bool) {
+	fd := val.FieldByName(name)
+	switch {
+	case !fd.IsValid():
+		return 0, false
+	case fd.CanInt():
+		return fd.Int(), true
+	case fd.CanUint():
+		return int64(fd.Uint()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,188 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pgError 模拟 PostgreSQL 驱动的错误
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string {
+	return "pg error " + e.code
+}
+
+func (e *pgError) SQLState() string {
+	return e.code
+}
+
+func TestDialect_translateErr(t *testing.T) {
+	otherErr := errors.New("other error")
+	mysqlErr := &mysql.MySQLError{Number: 1064}
+	pgErr := &pgError{code: "42601"}
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		err     error
+
+		wantErr error
+	}{
+		{
+			name:    "nil",
+			dialect: DialectMySQL,
+		},
+		{
+			name:    "mysql duplicate key",
+			dialect: DialectMySQL,
+			err:     &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"},
+			wantErr: errs.ErrDuplicateKey,
+		},
+		{
+			name:    "mysql foreign key",
+			dialect: DialectMySQL,
+			err:     &mysql.MySQLError{Number: 1452},
+			wantErr: errs.ErrForeignKeyViolation,
+		},
+		{
+			name:    "mysql deadlock",
+			dialect: DialectMySQL,
+			// 被别人包装过也可以识别
+			err:     fmt.Errorf("query: %w", &mysql.MySQLError{Number: 1213}),
+			wantErr: errs.ErrDeadlock,
+		},
+		{
+			name:    "mysql lock wait timeout",
+			dialect: DialectMySQL,
+			err:     &mysql.MySQLError{Number: 1205},
+			wantErr: errs.ErrLockWaitTimeout,
+		},
+		{
+			name:    "mysql unknown number",
+			dialect: DialectMySQL,
+			err:     mysqlErr,
+			wantErr: mysqlErr,
+		},
+		{
+			name:    "mysql other error",
+			dialect: DialectMySQL,
+			err:     otherErr,
+			wantErr: otherErr,
+		},
+		{
+			name:    "postgre duplicate key",
+			dialect: DialectPostgreSQL,
+			err:     &pgError{code: "23505"},
+			wantErr: errs.ErrDuplicateKey,
+		},
+		{
+			name:    "postgre deadlock",
+			dialect: DialectPostgreSQL,
+			err:     &pgError{code: "40P01"},
+			wantErr: errs.ErrDeadlock,
+		},
+		{
+			name:    "postgre serialization failure",
+			dialect: DialectPostgreSQL,
+			err:     &pgError{code: "40001"},
+			wantErr: errs.ErrSerializationFailure,
+		},
+		{
+			name:    "postgre unknown state",
+			dialect: DialectPostgreSQL,
+			err:     pgErr,
+			wantErr: pgErr,
+		},
+		{
+			name:    "sqlite foreign key",
+			dialect: DialectSQLite,
+			err:     sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey},
+			wantErr: errs.ErrForeignKeyViolation,
+		},
+		{
+			name:    "sqlite busy",
+			dialect: DialectSQLite,
+			err:     sqlite3.Error{Code: sqlite3.ErrBusy},
+			wantErr: errs.ErrLockWaitTimeout,
+		},
+		{
+			name:    "sqlite other error",
+			dialect: DialectSQLite,
+			err:     otherErr,
+			wantErr: otherErr,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.dialect.translateErr(tc.err)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.wantErr))
+		})
+	}
+}
+
+func TestDB_TranslateErr(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT .*").WillReturnError(&mysql.MySQLError{Number: 1062})
+	err = NewInserter[TestModel](db).Values(&TestModel{Id: 1}).Exec(context.Background()).Err()
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	// 依旧可以拿到驱动的错误
+	var me *mysql.MySQLError
+	require.True(t, errors.As(err, &me))
+	assert.Equal(t, uint16(1062), me.Number)
+
+	mock.ExpectQuery("SELECT .*").WillReturnError(&mysql.MySQLError{Number: 1213})
+	_, err = NewSelector[TestModel](db).Get(context.Background())
+	assert.True(t, errors.Is(err, ErrDeadlock))
+
+	// 提交的时候才发现死锁
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(&mysql.MySQLError{Number: 1213})
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		return nil
+	}, nil)
+	assert.True(t, errors.Is(err, ErrDeadlock))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_TranslateErr_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:translate_err.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec("CREATE TABLE savepoint_model(id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = NewInserter[SavepointModel](db).Values(&SavepointModel{Id: 1}).Exec(ctx).Err()
+	require.NoError(t, err)
+	err = NewInserter[SavepointModel](db).Values(&SavepointModel{Id: 1}).Exec(ctx).Err()
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	var se sqlite3.Error
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, sqlite3.ErrConstraintPrimaryKey, se.ExtendedCode)
+
+	// 事务里面也一样
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return NewInserter[SavepointModel](tx).Values(&SavepointModel{Id: 1}).Exec(ctx).Err()
+	}, nil)
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+}
@@ -2,4 +2,15 @@ package orm
 
 import "github.com/jackycsl/geektime-go-practical/orm/internal/errs"
 
-var ErrNoRows = errs.ErrNoRows
+var (
+	ErrNoRows = errs.ErrNoRows
+	// ErrOptimisticLockConflict 可以用 errors.Is 判断是否是乐观锁冲突
+	ErrOptimisticLockConflict = errs.ErrOptimisticLockConflict
+
+	// 数据库错误的分类，用 errors.Is 判断
+	ErrDuplicateKey         = errs.ErrDuplicateKey
+	ErrForeignKeyViolation  = errs.ErrForeignKeyViolation
+	ErrDeadlock             = errs.ErrDeadlock
+	ErrLockWaitTimeout      = errs.ErrLockWaitTimeout
+	ErrSerializationFailure = errs.ErrSerializationFailure
+)
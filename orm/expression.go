@@ -11,6 +11,8 @@ type RawExpr struct {
 	args []any
 }
 
+// Raw 里面的参数用 ? 作为占位符，构造的时候会换成方言的占位符
+// 例如 PostgreSQL 下会变成 $1，所以不要在 expr 里面直接使用 ? 操作符
 func Raw(expr string, args ...any) RawExpr {
 	return RawExpr{
 		raw:  expr,
@@ -26,3 +28,145 @@ func (r RawExpr) AsPredicate() Predicate {
 		left: r,
 	}
 }
+
+type MathExpr struct {
+	left  Expression
+	op    op
+	right Expression
+	alias string
+}
+
+func (m MathExpr) expr()       {}
+func (m MathExpr) selectable() {}
+
+// As 用在 SELECT 里面
+func (m MathExpr) As(alias string) MathExpr {
+	m.alias = alias
+	return m
+}
+
+// Add 例如 C("Age").Add(1).Add(C("Id"))
+func (m MathExpr) Add(val any) MathExpr {
+	return mathOf(m, opAdd, val)
+}
+
+func (m MathExpr) Sub(val any) MathExpr {
+	return mathOf(m, opSub, val)
+}
+
+func (m MathExpr) Multiply(val any) MathExpr {
+	return mathOf(m, opMultiply, val)
+}
+
+func (m MathExpr) Divide(val any) MathExpr {
+	return mathOf(m, opDivide, val)
+}
+
+// Eq 例如 C("Age").Add(1).Eq(18)，可以用在 WHERE 和 HAVING 里面
+func (m MathExpr) Eq(arg any) Predicate {
+	return m.binary(opEq, arg)
+}
+
+func (m MathExpr) NEQ(arg any) Predicate {
+	return m.binary(opNEQ, arg)
+}
+
+func (m MathExpr) LT(arg any) Predicate {
+	return m.binary(opLT, arg)
+}
+
+func (m MathExpr) LE(arg any) Predicate {
+	return m.binary(opLE, arg)
+}
+
+func (m MathExpr) GT(arg any) Predicate {
+	return m.binary(opGT, arg)
+}
+
+func (m MathExpr) GE(arg any) Predicate {
+	return m.binary(opGE, arg)
+}
+
+func (m MathExpr) binary(o op, arg any) Predicate {
+	m.alias = ""
+	return Predicate{
+		left:  m,
+		op:    o,
+		right: valueOf(arg),
+	}
+}
+
+func mathOf(left Expression, o op, right any) MathExpr {
+	// 别名只对最外层有意义
+	if m, ok := left.(MathExpr); ok {
+		m.alias = ""
+		left = m
+	}
+	return MathExpr{
+		left:  left,
+		op:    o,
+		right: valueOf(right),
+	}
+}
+
+// FuncExpr 代表 SQL 函数调用
+type FuncExpr struct {
+	fn    string
+	args  []Expression
+	alias string
+}
+
+// Fn 例如 Fn("COALESCE", C("LastName"), "anon")
+// 参数如果是 Column 之类的表达式，就会被构造成对应的列，否则会作为参数传入
+func Fn(fn string, args ...any) FuncExpr {
+	exprs := make([]Expression, 0, len(args))
+	for _, arg := range args {
+		exprs = append(exprs, valueOf(arg))
+	}
+	return FuncExpr{
+		fn:   fn,
+		args: exprs,
+	}
+}
+
+func (f FuncExpr) expr()       {}
+func (f FuncExpr) selectable() {}
+
+func (f FuncExpr) As(alias string) FuncExpr {
+	f.alias = alias
+	return f
+}
+
+// Eq 例如 Fn("LOWER", C("FirstName")).Eq("tom")
+func (f FuncExpr) Eq(arg any) Predicate {
+	return f.binary(opEq, arg)
+}
+
+func (f FuncExpr) NEQ(arg any) Predicate {
+	return f.binary(opNEQ, arg)
+}
+
+func (f FuncExpr) LT(arg any) Predicate {
+	return f.binary(opLT, arg)
+}
+
+func (f FuncExpr) LE(arg any) Predicate {
+	return f.binary(opLE, arg)
+}
+
+func (f FuncExpr) GT(arg any) Predicate {
+	return f.binary(opGT, arg)
+}
+
+func (f FuncExpr) GE(arg any) Predicate {
+	return f.binary(opGE, arg)
+}
+
+func (f FuncExpr) binary(o op, arg any) Predicate {
+	f.alias = ""
+	return Predicate{
+		left:  f,
+		op:    o,
+		right: valueOf(arg),
+	}
+}
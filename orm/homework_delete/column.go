@@ -39,6 +39,14 @@ func (c Column) LT(arg any) Predicate {
 	}
 }
 
+// IsNull 例如 C("DeletedAt").IsNull()
+func (c Column) IsNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNull,
+	}
+}
+
 func (c Column) GT(arg any) Predicate {
 	return Predicate{
 		left:  c,
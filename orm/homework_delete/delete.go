@@ -11,24 +11,33 @@ type Deleter[T any] struct {
 	args  []any
 	table string
 	where []Predicate
+	// unscoped 为 true 的时候，即便有软删除列也执行 DELETE
+	unscoped bool
 }
 
 func (d *Deleter[T]) Build() (*Query, error) {
-	d.sb.WriteString("DELETE FROM ")
-	if d.table == "" {
-		var t T
-		d.sb.WriteByte('`')
-		d.sb.WriteString(reflect.TypeOf(t).Name())
-		d.sb.WriteByte('`')
+	where := d.where
+	softDelete, ok := d.softDeleteColumn()
+	if ok {
+		// 软删除，已经删除的数据不需要再更新一次
+		d.sb.WriteString("UPDATE ")
+		d.buildTable()
+		d.sb.WriteString(" SET ")
+		if err := d.buildExpression(softDelete); err != nil {
+			return nil, err
+		}
+		d.sb.WriteString("=NOW()")
+		where = append(where[:len(where):len(where)], softDelete.IsNull())
 	} else {
-		d.sb.WriteString(d.table)
+		d.sb.WriteString("DELETE FROM ")
+		d.buildTable()
 	}
 
-	if len(d.where) > 0 {
+	if len(where) > 0 {
 		d.sb.WriteString(" WHERE ")
-		p := d.where[0]
-		for i := 1; i < len(d.where); i++ {
-			p = p.And(d.where[i])
+		p := where[0]
+		for i := 1; i < len(where); i++ {
+			p = p.And(where[i])
 		}
 		if err := d.buildExpression(p); err != nil {
 			return nil, err
@@ -41,6 +50,39 @@ func (d *Deleter[T]) Build() (*Query, error) {
 	}, nil
 }
 
+func (d *Deleter[T]) buildTable() {
+	if d.table == "" {
+		var t T
+		d.sb.WriteByte('`')
+		d.sb.WriteString(reflect.TypeOf(t).Name())
+		d.sb.WriteByte('`')
+	} else {
+		d.sb.WriteString(d.table)
+	}
+}
+
+// softDeleteColumn 找到标记了 orm:"soft_delete" 的字段
+// 这里没有元数据，列名就是字段名
+func (d *Deleter[T]) softDeleteColumn() (Column, bool) {
+	if d.unscoped {
+		return Column{}, false
+	}
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return Column{}, false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		fd := typ.Field(i)
+		// 标签里面可能还有别的设置，例如 orm:"column=deleted_at,soft_delete"
+		for _, flag := range strings.Split(fd.Tag.Get("orm"), ",") {
+			if strings.TrimSpace(flag) == "soft_delete" {
+				return C(fd.Name), true
+			}
+		}
+	}
+	return Column{}, false
+}
+
 func (d *Deleter[T]) buildExpression(e Expression) error {
 	if e == nil {
 		return nil
@@ -67,6 +109,10 @@ func (d *Deleter[T]) buildExpression(e Expression) error {
 
 		d.sb.WriteByte(' ')
 		d.sb.WriteString(exp.op.String())
+		// IS NULL 没有右边
+		if exp.right == nil {
+			return nil
+		}
 		d.sb.WriteByte(' ')
 
 		_, rp := exp.right.(Predicate)
@@ -91,6 +137,12 @@ func (d *Deleter[T]) From(table string) *Deleter[T] {
 	return d
 }
 
+// Unscoped 忽略软删除，直接 DELETE
+func (d *Deleter[T]) Unscoped() *Deleter[T] {
+	d.unscoped = true
+	return d
+}
+
 // Where accepts predicates
 func (d *Deleter[T]) Where(predicates ...Predicate) *Deleter[T] {
 	d.where = predicates
@@ -1,6 +1,7 @@
 package homework_delete
 
 import (
+	"database/sql"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,6 +37,37 @@ func TestDeleter_Build(t *testing.T) {
 				Args: []any{16},
 			},
 		},
+		{
+			name:    "soft delete",
+			builder: (&Deleter[SoftDeleteModel]{}).Where(C("Id").EQ(16)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `SoftDeleteModel` SET `DeletedAt`=NOW() WHERE (`Id` = ?) AND (`DeletedAt` IS NULL);",
+				Args: []any{16},
+			},
+		},
+		{
+			name:    "soft delete no where",
+			builder: (&Deleter[SoftDeleteModel]{}).From("`soft_delete_model`"),
+			wantQuery: &Query{
+				SQL: "UPDATE `soft_delete_model` SET `DeletedAt`=NOW() WHERE `DeletedAt` IS NULL;",
+			},
+		},
+		{
+			name:    "soft delete with other tags",
+			builder: (&Deleter[TaggedSoftDeleteModel]{}).Where(C("Id").EQ(16)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `TaggedSoftDeleteModel` SET `DeletedAt`=NOW() WHERE (`Id` = ?) AND (`DeletedAt` IS NULL);",
+				Args: []any{16},
+			},
+		},
+		{
+			name:    "unscoped",
+			builder: (&Deleter[SoftDeleteModel]{}).Unscoped().Where(C("Id").EQ(16)),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `SoftDeleteModel` WHERE `Id` = ?;",
+				Args: []any{16},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -50,3 +82,13 @@ func TestDeleter_Build(t *testing.T) {
 		})
 	}
 }
+
+type SoftDeleteModel struct {
+	Id        int64
+	DeletedAt sql.NullTime `orm:"soft_delete"`
+}
+
+type TaggedSoftDeleteModel struct {
+	Id        int64
+	DeletedAt sql.NullTime `orm:"column=deleted_at,soft_delete"`
+}
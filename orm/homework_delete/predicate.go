@@ -11,6 +11,8 @@ const (
 	opAND = "AND"
 	opOR  = "OR"
 	opNOT = "NOT"
+
+	opIsNull = "IS NULL"
 )
 
 func (o op) String() string {
@@ -6,4 +6,6 @@ import "github.com/jackycsl/geektime-go-practical/orm/homework_subquery/internal
 var (
 	// ErrNoRows 代表没有找到数据
 	ErrNoRows = errs.ErrNoRows
+	// ErrOptimisticLockConflict 乐观锁冲突，数据已经被别人修改了
+	ErrOptimisticLockConflict = errs.ErrOptimisticLockConflict
 )
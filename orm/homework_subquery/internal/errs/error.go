@@ -17,6 +17,11 @@ var (
 	// ErrInsertZeroRow 代表插入 0 行
 	ErrInsertZeroRow = errors.New("orm: 插入 0 行")
 	ErrNoUpdatedColumns = errors.New("orm: 未指定更新的列")
+	// ErrOptimisticLockConflict 带版本列的更新没有影响任何行
+	// 说明数据已经被别人修改了，需要重新读取之后再更新
+	ErrOptimisticLockConflict = errors.New("orm: 乐观锁冲突，数据已经被修改")
+	// ErrUpdateVersion 版本号由 ORM 自己加一，用户不能更新
+	ErrUpdateVersion = errors.New("orm: 不能手动更新版本号列")
 )
 
 // NewErrUnknownField 返回代表未知字段的错误
@@ -67,6 +72,14 @@ func NewErrInvalidTagContent(tag string) error {
 	return fmt.Errorf("orm: 错误的标签设置: %s", tag)
 }
 
+func NewErrMultipleVersion(first, second string) error {
+	return fmt.Errorf("orm: 只能有一个版本列，但是 %s 和 %s 都声明了 version", first, second)
+}
+
+func NewErrInvalidVersionType(name string, typ any) error {
+	return fmt.Errorf("orm: 版本列 %s 必须是整数，但是它的类型是 %v", name, typ)
+}
+
 func NewErrFailToRollbackTx(bizErr error, rbErr error, panicked bool) error {
 	return fmt.Errorf("orm: 回滚事务失败, 业务错误 %w, 回滚错误 %s, panic: %t",
 		bizErr, rbErr.Error(), panicked)
@@ -11,6 +11,8 @@ type Model struct {
 	Fields []*Field
 	FieldMap  map[string]*Field
 	ColumnMap map[string]*Field
+	// Version 乐观锁的版本列，没有就是 nil
+	Version *Field
 }
 
 // Field 字段
@@ -27,6 +29,8 @@ type Field struct {
 // 方便用户查找，和我们后期维护
 const (
 	tagKeyColumn = "column"
+	// tagKeyVersion 乐观锁的版本列，只是一个标记，没有值
+	tagKeyVersion = "version"
 )
 
 // 用户自定义一些模型信息的接口，集中放在这里
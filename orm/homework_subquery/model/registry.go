@@ -70,6 +70,7 @@ func (r *registry) parseModel(val any) (*Model, error) {
 	fieldMap := make(map[string]*Field, numField)
 	fields := make([]*Field, 0, numField)
 	colMap := make(map[string]*Field, numField)
+	var version *Field
 	for i := 0; i < numField; i++ {
 		fdType := typ.Field(i)
 		tags, err := r.parseTag(fdType.Tag)
@@ -87,6 +88,15 @@ func (r *registry) parseModel(val any) (*Model, error) {
 			Offset:  fdType.Offset,
 			Index:   i,
 		}
+		if _, ok := tags[tagKeyVersion]; ok {
+			if version != nil {
+				return nil, errs.NewErrMultipleVersion(version.GoName, f.GoName)
+			}
+			if !isInteger(f.Type) {
+				return nil, errs.NewErrInvalidVersionType(f.GoName, f.Type)
+			}
+			version = f
+		}
 		fieldMap[fdType.Name] = f
 		fields = append(fields, f)
 		colMap[colName] = f
@@ -105,9 +115,20 @@ func (r *registry) parseModel(val any) (*Model, error) {
 		FieldMap:  fieldMap,
 		ColumnMap: colMap,
 		Fields:    fields,
+		Version:   version,
 	}, nil
 }
 
+// isInteger 版本列只能是整数
+func isInteger(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
 func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	ormTag := tag.Get("orm")
 	if ormTag == "" {
@@ -122,6 +143,11 @@ func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	pairs := strings.Split(ormTag, ",")
 	for _, pair := range pairs {
 		kv := strings.Split(pair, "=")
+		// version 没有值
+		if len(kv) == 1 && kv[0] == tagKeyVersion {
+			res[tagKeyVersion] = ""
+			continue
+		}
 		if len(kv) != 2 {
 			return nil, errs.NewErrInvalidTagContent(pair)
 		}
@@ -231,6 +231,27 @@ func TestRegistry_get(t *testing.T) {
 			}(),
 			wantErr: errs.NewErrInvalidTagContent("column"),
 		},
+		{
+			name: "multiple version",
+			val: func() any {
+				type MultipleVersion struct {
+					V1 int64 `orm:"version"`
+					V2 int64 `orm:"version"`
+				}
+				return &MultipleVersion{}
+			}(),
+			wantErr: errs.NewErrMultipleVersion("V1", "V2"),
+		},
+		{
+			name: "invalid version type",
+			val: func() any {
+				type InvalidVersion struct {
+					Version string `orm:"version"`
+				}
+				return &InvalidVersion{}
+			}(),
+			wantErr: errs.NewErrInvalidVersionType("Version", reflect.TypeOf("")),
+		},
 		{
 			// 如果用户设置了一些奇奇怪怪的内容，这部分内容我们会忽略掉
 			name: "ignore tag",
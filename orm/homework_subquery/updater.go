@@ -2,6 +2,8 @@ package orm
 
 import (
 	"context"
+	"database/sql"
+	"reflect"
 
 	"github.com/jackycsl/geektime-go-practical/orm/homework_subquery/internal/errs"
 )
@@ -40,10 +42,11 @@ func (u *Updater[T]) Build() (*Query, error) {
 	if len(u.assigns) == 0 {
 		return nil, errs.ErrNoUpdatedColumns
 	}
-	if u.val == nil {
-		u.val = new(T)
+	entity := u.val
+	if entity == nil {
+		entity = new(T)
 	}
-	model, err := u.r.Get(u.val)
+	model, err := u.r.Get(entity)
 	if err != nil {
 		return nil, err
 	}
@@ -51,11 +54,14 @@ func (u *Updater[T]) Build() (*Query, error) {
 	u.sb.WriteString("UPDATE ")
 	u.quote(model.TableName)
 	u.sb.WriteString(" SET ")
-	val := u.valCreator(u.val, model)
+	val := u.valCreator(entity, model)
 	for i, a := range u.assigns {
 		if i > 0 {
 			u.sb.WriteByte(',')
 		}
+		if u.isVersion(a) {
+			return nil, errs.ErrUpdateVersion
+		}
 		switch assign := a.(type) {
 		case Column:
 			if err = u.buildColumn(assign.table, assign.name); err != nil {
@@ -75,9 +81,26 @@ func (u *Updater[T]) Build() (*Query, error) {
 			return nil, errs.NewErrUnsupportedAssignableType(a)
 		}
 	}
-	if len(u.where) > 0 {
+	where := u.where
+	if version := model.Version; version != nil {
+		// `version`=`version`+1
+		u.sb.WriteByte(',')
+		u.quote(version.ColName)
+		u.sb.WriteByte('=')
+		u.quote(version.ColName)
+		u.sb.WriteString("+1")
+		// 只有传入了实体才知道原来的版本
+		if u.val != nil {
+			cur, err := val.Field(version.GoName)
+			if err != nil {
+				return nil, err
+			}
+			where = append(where[:len(where):len(where)], C(version.GoName).EQ(cur))
+		}
+	}
+	if len(where) > 0 {
 		u.sb.WriteString(" WHERE ")
-		if err = u.buildPredicates(u.where); err != nil {
+		if err = u.buildPredicates(where); err != nil {
 			return nil, err
 		}
 	}
@@ -107,5 +130,41 @@ func (u *Updater[T]) Exec(ctx context.Context) Result {
 		return Result{err: err}
 	}
 	res, err := u.sess.execContext(ctx, q.SQL, q.Args...)
+	if err == nil && u.val != nil && u.model.Version != nil {
+		err = u.checkVersion(res)
+	}
 	return Result{err: err, res: res}
 }
+
+func (u *Updater[T]) isVersion(assign Assignable) bool {
+	version := u.model.Version
+	if version == nil {
+		return false
+	}
+	switch a := assign.(type) {
+	case Column:
+		return a.name == version.GoName
+	case Assignment:
+		return a.column == version.GoName
+	}
+	return false
+}
+
+// checkVersion 没有影响任何行就是乐观锁冲突
+// 更新成功之后，实体里面的版本也要加一，这样才能继续用这个实体更新
+func (u *Updater[T]) checkVersion(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errs.ErrOptimisticLockConflict
+	}
+	fd := reflect.ValueOf(u.val).Elem().Field(u.model.Version.Index)
+	if fd.CanInt() {
+		fd.SetInt(fd.Int() + 1)
+	} else {
+		fd.SetUint(fd.Uint() + 1)
+	}
+	return nil
+}
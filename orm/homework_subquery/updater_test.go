@@ -1,10 +1,13 @@
 package orm
 
 import (
+	"context"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jackycsl/geektime-go-practical/orm/homework_subquery/internal/errs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUpdater_Build(t *testing.T) {
@@ -75,6 +78,31 @@ func TestUpdater_Build(t *testing.T) {
 				Args: []any{1},
 			},
 		},
+		{
+			name: "version",
+			u: NewUpdater[VersionModel](db).Update(&VersionModel{
+				Name:    "Tom",
+				Version: 3,
+			}).Set(C("Name")).Where(C("Id").EQ(1)),
+			want: &Query{
+				SQL:  "UPDATE `version_model` SET `name`=?,`version`=`version`+1 WHERE (`id` = ?) AND (`version` = ?);",
+				Args: []any{"Tom", 1, int64(3)},
+			},
+		},
+		{
+			// 没有实体就不知道原来的版本
+			name: "version without entity",
+			u:    NewUpdater[VersionModel](db).Set(Assign("Name", "Tom")),
+			want: &Query{
+				SQL:  "UPDATE `version_model` SET `name`=?,`version`=`version`+1;",
+				Args: []any{"Tom"},
+			},
+		},
+		{
+			name:    "update version",
+			u:       NewUpdater[VersionModel](db).Set(Assign("Version", 10)),
+			wantErr: errs.ErrUpdateVersion,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -87,3 +115,36 @@ func TestUpdater_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdater_Version(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// 更新成功之后实体里面的版本号也加一
+	mock.ExpectExec("UPDATE .*").WithArgs("Tom", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	val := &VersionModel{Name: "Tom", Version: 3}
+	err = NewUpdater[VersionModel](db).Update(val).Set(C("Name")).
+		Exec(context.Background()).Err()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), val.Version)
+
+	// 没有影响任何行就是被别人修改了
+	mock.ExpectExec("UPDATE .*").WithArgs("Jerry", int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	val.Name = "Jerry"
+	err = NewUpdater[VersionModel](db).Update(val).Set(C("Name")).
+		Exec(context.Background()).Err()
+	assert.Equal(t, ErrOptimisticLockConflict, err)
+	assert.Equal(t, int64(4), val.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type VersionModel struct {
+	Id      int64
+	Name    string
+	Version int64 `orm:"version"`
+}
@@ -0,0 +1,69 @@
+package orm
+
+import "context"
+
+// 实体可以实现下面这些接口，在执行语句前后做一些处理
+// 例如在 BeforeInsert 里面设置 CreatedAt，在 AfterFind 里面规范化数据
+// 任何一个钩子返回 error 都会中断执行，并且把 error 返回给用户
+
+// BeforeInsert 在 INSERT 语句执行之前调用，批量插入的时候每个实体都会调用
+type BeforeInsert interface {
+	BeforeInsert(ctx context.Context, qc *QueryContext) error
+}
+
+// AfterInsert 在 INSERT 语句执行成功之后调用
+// 自增主键已经回写到实体上了
+type AfterInsert interface {
+	AfterInsert(ctx context.Context, qc *QueryContext) error
+}
+
+// BeforeUpdate 在 UPDATE 语句执行之前调用
+// 只有通过 Updater.Update 传入了实体才会调用
+type BeforeUpdate interface {
+	BeforeUpdate(ctx context.Context, qc *QueryContext) error
+}
+
+// AfterUpdate 在 UPDATE 语句执行成功之后调用
+type AfterUpdate interface {
+	AfterUpdate(ctx context.Context, qc *QueryContext) error
+}
+
+// AfterFind 在每一行数据读取到实体之后调用
+type AfterFind interface {
+	AfterFind(ctx context.Context, qc *QueryContext) error
+}
+
+func beforeInsert(ctx context.Context, qc *QueryContext, entity any) error {
+	if h, ok := entity.(BeforeInsert); ok {
+		return h.BeforeInsert(ctx, qc)
+	}
+	return nil
+}
+
+func afterInsert(ctx context.Context, qc *QueryContext, entity any) error {
+	if h, ok := entity.(AfterInsert); ok {
+		return h.AfterInsert(ctx, qc)
+	}
+	return nil
+}
+
+func beforeUpdate(ctx context.Context, qc *QueryContext, entity any) error {
+	if h, ok := entity.(BeforeUpdate); ok {
+		return h.BeforeUpdate(ctx, qc)
+	}
+	return nil
+}
+
+func afterUpdate(ctx context.Context, qc *QueryContext, entity any) error {
+	if h, ok := entity.(AfterUpdate); ok {
+		return h.AfterUpdate(ctx, qc)
+	}
+	return nil
+}
+
+func afterFind(ctx context.Context, qc *QueryContext, entity any) error {
+	if h, ok := entity.(AfterFind); ok {
+		return h.AfterFind(ctx, qc)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHook_Insert(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// BeforeInsert 设置的值会被插入
+	mock.ExpectExec("INSERT .*").
+		WithArgs(int64(1), "Tom", int64(100), int64(0)).
+		WillReturnResult(driver.RowsAffected(1))
+	entity := &HookModel{Id: 1, Name: "Tom"}
+	res := NewInserter[HookModel](db).Values(entity).Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.Equal(t, []string{"BeforeInsert INSERT", "AfterInsert INSERT"}, entity.calls)
+
+	// BeforeInsert 返回 error 就不会执行
+	entity = &HookModel{Id: 2, Name: "before error"}
+	res = NewInserter[HookModel](db).Values(entity).Exec(context.Background())
+	assert.Equal(t, errors.New("before error"), res.Err())
+
+	// 执行失败不会调用 AfterInsert
+	mock.ExpectExec("INSERT .*").WillReturnError(errors.New("db error"))
+	entity = &HookModel{Id: 3, Name: "Jerry"}
+	res = NewInserter[HookModel](db).Values(entity).Exec(context.Background())
+	assert.Equal(t, errors.New("db error"), res.Err())
+	assert.Equal(t, []string{"BeforeInsert INSERT"}, entity.calls)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHook_Update(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE .*").
+		WithArgs("Tom", int64(200), int64(1)).
+		WillReturnResult(driver.RowsAffected(1))
+	entity := &HookModel{Id: 1, Name: "Tom"}
+	res := NewUpdater[HookModel](db).Update(entity).
+		Set(C("Name"), C("UpdatedAt")).Where(C("Id").Eq(int64(1))).Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.Equal(t, []string{"BeforeUpdate UPDATE", "AfterUpdate UPDATE"}, entity.calls)
+
+	entity = &HookModel{Id: 1, Name: "before error"}
+	res = NewUpdater[HookModel](db).Update(entity).
+		Set(C("Name")).Exec(context.Background())
+	assert.Equal(t, errors.New("before error"), res.Err())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHook_AfterFind(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	newRows := func(names ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "name"})
+		for i, name := range names {
+			rows.AddRow(i+1, name)
+		}
+		return rows
+	}
+
+	// AfterFind 把名字转成大写
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows("tom"))
+	res, err := NewSelector[HookModel](db).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOM", res.Name)
+	assert.Equal(t, []string{"AfterFind SELECT"}, res.calls)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows("tom", "jerry"))
+	ress, err := NewSelector[HookModel](db).GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "TOM", ress[0].Name)
+	assert.Equal(t, "JERRY", ress[1].Name)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows("tom"))
+	res, err = RawQuery[HookModel](db, "SELECT * FROM `hook_model`").Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AfterFind RAW"}, res.calls)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows("tom"))
+	it := NewSelector[HookModel](db).Iter(context.Background())
+	require.True(t, it.Next())
+	assert.Equal(t, "TOM", it.Value().Name)
+	require.NoError(t, it.Close())
+
+	// AfterFind 返回 error
+	mock.ExpectQuery("SELECT .*").WillReturnRows(newRows("tom", "find error"))
+	_, err = NewSelector[HookModel](db).GetMulti(context.Background())
+	assert.Equal(t, errors.New("find error"), err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type HookModel struct {
+	Id        int64
+	Name      string
+	CreatedAt int64
+	UpdatedAt int64
+
+	calls []string `orm:"-"`
+}
+
+func (h *HookModel) BeforeInsert(ctx context.Context, qc *QueryContext) error {
+	h.calls = append(h.calls, "BeforeInsert "+qc.Type)
+	if h.Name == "before error" {
+		return errors.New("before error")
+	}
+	h.CreatedAt = 100
+	return nil
+}
+
+func (h *HookModel) AfterInsert(ctx context.Context, qc *QueryContext) error {
+	h.calls = append(h.calls, "AfterInsert "+qc.Type)
+	return nil
+}
+
+func (h *HookModel) BeforeUpdate(ctx context.Context, qc *QueryContext) error {
+	h.calls = append(h.calls, "BeforeUpdate "+qc.Type)
+	if h.Name == "before error" {
+		return errors.New("before error")
+	}
+	h.UpdatedAt = 200
+	return nil
+}
+
+func (h *HookModel) AfterUpdate(ctx context.Context, qc *QueryContext) error {
+	h.calls = append(h.calls, "AfterUpdate "+qc.Type)
+	return nil
+}
+
+func (h *HookModel) AfterFind(ctx context.Context, qc *QueryContext) error {
+	if h.Name == "find error" {
+		return errors.New("find error")
+	}
+	h.calls = append(h.calls, "AfterFind "+qc.Type)
+	h.Name = strings.ToUpper(h.Name)
+	return nil
+}
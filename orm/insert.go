@@ -3,6 +3,7 @@ package orm
 import (
 	"context"
 	"database/sql"
+	"reflect"
 
 	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
 	"github.com/jackycsl/geektime-go-practical/orm/model"
@@ -44,6 +45,10 @@ type Inserter[T any] struct {
 
 	// onDuplicateKey []Assignable
 	onDuplicateKey *Upsert
+
+	// batchSize 大于 0 的时候，每 batchSize 行生成一条 INSERT 语句
+	batchSize int
+	batchInTx bool
 }
 
 func NewInserter[T any](sess Session) *Inserter[T] {
@@ -73,6 +78,21 @@ func (i *Inserter[T]) Values(vals ...*T) *Inserter[T] {
 	return i
 }
 
+// BatchSize 把 Values 拆分成多条 INSERT 语句，每条最多 n 行
+// 避免一条语句超过 max_allowed_packet 或者占位符数量的限制
+// 每一批各自执行，RowsAffected 是所有批次的总和
+func (i *Inserter[T]) BatchSize(n int) *Inserter[T] {
+	i.batchSize = n
+	return i
+}
+
+// BatchInTx 分批插入的时候在同一个事务里面执行，任何一批失败都会回滚
+// sess 本身就是事务的时候，直接在这个事务里面执行
+func (i *Inserter[T]) BatchInTx() *Inserter[T] {
+	i.batchInTx = true
+	return i
+}
+
 func (i *Inserter[T]) Columns(cols ...string) *Inserter[T] {
 	i.columns = cols
 	return i
@@ -82,6 +102,7 @@ func (i *Inserter[T]) Build() (*Query, error) {
 	if len(i.values) == 0 {
 		return nil, errs.ErrInsertZeroRow
 	}
+	i.reset()
 	i.sb.WriteString("INSERT INTO ")
 	if i.model == nil {
 		m, err := i.r.Get(i.values[0])
@@ -98,6 +119,15 @@ func (i *Inserter[T]) Build() (*Query, error) {
 	i.sb.WriteByte('(')
 
 	fields := i.model.Fields
+	if len(i.columns) == 0 && i.omitAutoIncrement() {
+		// 没有指定列的时候，自增列交给数据库生成
+		fields = make([]*model.Field, 0, len(i.model.Fields)-1)
+		for _, fd := range i.model.Fields {
+			if !fd.AutoIncrement {
+				fields = append(fields, fd)
+			}
+		}
+	}
 	if len(i.columns) > 0 {
 		fields = make([]*model.Field, 0, len(i.columns))
 		for _, fd := range i.columns {
@@ -132,12 +162,11 @@ func (i *Inserter[T]) Build() (*Query, error) {
 			if idx > 0 {
 				i.sb.WriteByte(',')
 			}
-			i.sb.WriteByte('?')
 			arg, err := val.Field(field.GoName)
 			if err != nil {
 				return nil, err
 			}
-			i.addArg(arg)
+			i.parameter(arg)
 		}
 		i.sb.WriteByte(')')
 	}
@@ -190,12 +219,74 @@ func (i *Inserter[T]) Exec(ctx context.Context) Result {
 			err: err,
 		}
 	}
+	if sd, ok := i.sess.(*ShardingDB); ok {
+		return i.execSharding(ctx, sd)
+	}
+	return i.execBatch(ctx, i.sess)
+}
 
-	res := exec(ctx, i.sess, i.core, &QueryContext{
+// txDoer 能够开启事务的 Session，例如 DB 和 Cluster
+type txDoer interface {
+	DoTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error, opts *sql.TxOptions) error
+}
+
+func (i *Inserter[T]) execBatch(ctx context.Context, sess Session) Result {
+	if i.batchSize <= 0 || len(i.values) <= i.batchSize {
+		return i.exec(ctx, sess)
+	}
+	if d, ok := sess.(txDoer); ok && i.batchInTx {
+		// ctx 里面已经有事务的时候直接加入
+		var res Result
+		err := d.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+			res = i.execBatches(ctx, tx)
+			return res.err
+		}, nil)
+		// 回滚之后前面批次插入的数据也不存在了，所以只返回错误
+		if err != nil {
+			return Result{err: err}
+		}
+		return res
+	}
+	return i.execBatches(ctx, sess)
+}
+
+// execBatches 已经执行成功的批次不会因为后面的批次失败而撤销
+func (i *Inserter[T]) execBatches(ctx context.Context, sess Session) Result {
+	vals := i.values
+	defer func() {
+		i.values = vals
+	}()
+	res := multiResult{results: make([]sql.Result, 0, (len(vals)+i.batchSize-1)/i.batchSize)}
+	for start := 0; start < len(vals); start += i.batchSize {
+		end := start + i.batchSize
+		if end > len(vals) {
+			end = len(vals)
+		}
+		i.values = vals[start:end]
+		r := i.exec(ctx, sess)
+		if r.err != nil {
+			return Result{err: r.err, res: res}
+		}
+		res.results = append(res.results, r.res)
+	}
+	return Result{res: res}
+}
+
+func (i *Inserter[T]) exec(ctx context.Context, sess Session) Result {
+	var err error
+	qc := &QueryContext{
 		Type:    "INSERT",
 		Builder: i,
 		Model:   i.model,
-	})
+	}
+	for _, val := range i.values {
+		if err = beforeInsert(ctx, qc, val); err != nil {
+			return Result{
+				err: err,
+			}
+		}
+	}
+	res := exec(ctx, sess, i.core, qc)
 	// var t *T
 	// if val, ok := res.Result.(*T); ok {
 	// 	t = val
@@ -205,12 +296,65 @@ func (i *Inserter[T]) Exec(ctx context.Context) Result {
 	if res.Result != nil {
 		sqlRes = res.Result.(sql.Result)
 	}
+	if res.Err == nil {
+		i.setAutoIncrement(sqlRes)
+		for _, val := range i.values {
+			if err = afterInsert(ctx, qc, val); err != nil {
+				return Result{
+					err: err,
+					res: sqlRes,
+				}
+			}
+		}
+	}
 	return Result{
 		err: res.Err,
 		res: sqlRes,
 	}
 }
 
+// omitAutoIncrement 所有行的自增列都是零值的时候才交给数据库生成
+// 只要有一行用户自己指定了 ID，就要带上自增列
+func (i *Inserter[T]) omitAutoIncrement() bool {
+	fd := i.model.AutoIncrement
+	if fd == nil {
+		return false
+	}
+	for _, v := range i.values {
+		fdVal, err := reflect.ValueOf(v).Elem().FieldByIndexErr(fd.Index)
+		// 组合的指针是 nil，也就是零值
+		if err == nil && !fdVal.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// setAutoIncrement 把数据库生成的自增主键写回实体
+// 批量插入的时候，LastInsertId 只是第一行的 ID，并且 ID 不一定连续，所以只处理单行
+// 用户自己指定了 ID 的时候不回写
+// 有些驱动不支持 LastInsertId，例如 PostgreSQL，这时候也不回写
+func (i *Inserter[T]) setAutoIncrement(res sql.Result) {
+	fd := i.model.AutoIncrement
+	if fd == nil || res == nil || len(i.values) != 1 {
+		return
+	}
+	fdVal, err := reflect.ValueOf(i.values[0]).Elem().FieldByIndexErr(fd.Index)
+	// 组合的指针是 nil
+	if err != nil || !fdVal.IsZero() {
+		return
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return
+	}
+	val := reflect.ValueOf(id)
+	if !val.CanConvert(fd.Type) {
+		return
+	}
+	fdVal.Set(val.Convert(fd.Type))
+}
+
 // var _ Handler = (&Inserter[any]{}).execHandler
 
 // func (i *Inserter[T]) execHandler(ctx context.Context, qc *QueryContext) *QueryResult {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -57,6 +58,82 @@ func TestInserter_SQLite_upsert(t *testing.T) {
 					int64(13), "DaMing", int8(19), &sql.NullString{String: "Deng", Valid: true}},
 			},
 		},
+		{
+			name: "upsert-update expression",
+			i: NewInserter[TestModel](db).Values(&TestModel{
+				Id:  12,
+				Age: 18,
+			}).OnDuplicateKey().ConflictColumns("Id").Update(Assign("Age", C("Age").Add(1))),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `test_model`(`id`,`first_name`,`age`,`last_name`) VALUES (?,?,?,?) " +
+					"ON CONFLICT(`id`) DO UPDATE SET `age`=`age` + ?;",
+				Args: []any{int64(12), "", int8(18), (*sql.NullString)(nil), 1},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.i.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestInserter_PostgreSQL_upsert(t *testing.T) {
+	db := memoryDB(t, DBWithDialect(DialectPostgreSQL))
+	testCases := []struct {
+		name string
+		i    QueryBuilder
+
+		wantErr   error
+		wantQuery *Query
+	}{
+		{
+			name: "upsert-update value",
+			i: NewInserter[TestModel](db).Values(&TestModel{
+				Id:        12,
+				FirstName: "Tom",
+				Age:       18,
+				LastName:  &sql.NullString{String: "Jerry", Valid: true},
+			}).OnDuplicateKey().ConflictColumns("Id").Update(Assign("FirstName", "Deng"),
+				Assign("Age", 19)),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "test_model"("id","first_name","age","last_name") VALUES ($1,$2,$3,$4) ` +
+					`ON CONFLICT("id") DO UPDATE SET "first_name"=$5,"age"=$6;`,
+				Args: []any{int64(12), "Tom", int8(18), &sql.NullString{String: "Jerry", Valid: true}, "Deng", 19},
+			},
+		},
+		{
+			name: "upsert-update column",
+			i: NewInserter[TestModel](db).Values(&TestModel{
+				Id:        12,
+				FirstName: "Tom",
+				Age:       18,
+				LastName:  &sql.NullString{String: "Jerry", Valid: true},
+			}, &TestModel{
+				Id:        13,
+				FirstName: "DaMing",
+				Age:       19,
+				LastName:  &sql.NullString{String: "Deng", Valid: true},
+			}).OnDuplicateKey().ConflictColumns("FirstName", "LastName").Update(C("FirstName"), C("Age")),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "test_model"("id","first_name","age","last_name") VALUES ($1,$2,$3,$4),($5,$6,$7,$8) ` +
+					`ON CONFLICT("first_name","last_name") DO UPDATE SET "first_name"=EXCLUDED."first_name","age"=EXCLUDED."age";`,
+				Args: []any{int64(12), "Tom", int8(18), &sql.NullString{String: "Jerry", Valid: true},
+					int64(13), "DaMing", int8(19), &sql.NullString{String: "Deng", Valid: true}},
+			},
+		},
+		{
+			name: "upsert without conflict columns",
+			i: NewInserter[TestModel](db).Values(&TestModel{
+				Id: 12,
+			}).OnDuplicateKey().Update(C("FirstName")),
+			wantErr: errs.ErrNoConflictColumns,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -169,6 +246,19 @@ func TestInserter_Build(t *testing.T) {
 					int64(13), "DaMing", int8(19), &sql.NullString{String: "Deng", Valid: true}},
 			},
 		},
+		{
+			name: "upsert-update expression",
+			i: NewInserter[TestModel](db).Values(&TestModel{
+				Id:  12,
+				Age: 18,
+			}).OnDuplicateKey().Update(Assign("Age", C("Age").Add(1)),
+				Assign("FirstName", Fn("CONCAT", C("FirstName"), "-copy"))),
+			wantQuery: &Query{
+				SQL: "INSERT INTO `test_model`(`id`,`first_name`,`age`,`last_name`) VALUES (?,?,?,?) " +
+					"ON DUPLICATE KEY UPDATE `age`=`age` + ?,`first_name`=CONCAT(`first_name`,?);",
+				Args: []any{int64(12), "", int8(18), (*sql.NullString)(nil), 1, "-copy"},
+			},
+		},
 	}
 
 	for _, tc := range testsCases {
@@ -183,6 +273,57 @@ func TestInserter_Build(t *testing.T) {
 	}
 }
 
+func TestInserter_AutoIncrement(t *testing.T) {
+	type AutoIncrementModel struct {
+		Id        int64 `orm:"pk,auto_increment"`
+		FirstName string
+	}
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	q, err := NewInserter[AutoIncrementModel](db).Values(&AutoIncrementModel{FirstName: "Tom"},
+		&AutoIncrementModel{FirstName: "Jerry"}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `auto_increment_model`(`first_name`) VALUES (?),(?);",
+		Args: []any{"Tom", "Jerry"},
+	}, q)
+
+	// 用户自己指定了 ID，不能丢掉
+	q, err = NewInserter[AutoIncrementModel](db).Values(&AutoIncrementModel{Id: 42, FirstName: "Tom"},
+		&AutoIncrementModel{FirstName: "Jerry"}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `auto_increment_model`(`id`,`first_name`) VALUES (?,?),(?,?);",
+		Args: []any{int64(42), "Tom", int64(0), "Jerry"},
+	}, q)
+
+	// 显式指定了列，就按照用户指定的来
+	q, err = NewInserter[AutoIncrementModel](db).Columns("Id", "FirstName").
+		Values(&AutoIncrementModel{Id: 12, FirstName: "Tom"}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "INSERT INTO `auto_increment_model`(`id`,`first_name`) VALUES (?,?);",
+		Args: []any{int64(12), "Tom"},
+	}, q)
+
+	mock.ExpectExec("INSERT INTO .*").WillReturnResult(sqlmock.NewResult(15, 1))
+	entity := &AutoIncrementModel{FirstName: "Tom"}
+	res := NewInserter[AutoIncrementModel](db).Values(entity).Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.Equal(t, int64(15), entity.Id)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `auto_increment_model`(`id`,`first_name`) VALUES (?,?);")).
+		WithArgs(int64(42), "Tom").WillReturnResult(sqlmock.NewResult(42, 1))
+	entity = &AutoIncrementModel{Id: 42, FirstName: "Tom"}
+	res = NewInserter[AutoIncrementModel](db).Values(entity).Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.Equal(t, int64(42), entity.Id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestInserter_Exec(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -234,3 +375,131 @@ func TestInserter_Exec(t *testing.T) {
 		})
 	}
 }
+
+func TestInserter_BatchSize(t *testing.T) {
+	dbErr := errors.New("db error")
+	single := regexp.QuoteMeta("INSERT INTO `test_model`(`id`,`first_name`,`age`,`last_name`) VALUES (?,?,?,?);")
+	double := regexp.QuoteMeta("INSERT INTO `test_model`(`id`,`first_name`,`age`,`last_name`) VALUES (?,?,?,?),(?,?,?,?);")
+	testCases := []struct {
+		name      string
+		batchSize int
+		inTx      bool
+		mock      func(mock sqlmock.Sqlmock)
+
+		wantErr      error
+		wantAffected int64
+	}{
+		{
+			name: "no batch",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO .*").
+					WillReturnResult(driver.RowsAffected(5))
+			},
+			wantAffected: 5,
+		},
+		{
+			name:      "batch",
+			batchSize: 2,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(double).WithArgs(1, "", 0, nil, 2, "", 0, nil).
+					WillReturnResult(driver.RowsAffected(2))
+				mock.ExpectExec(double).WithArgs(3, "", 0, nil, 4, "", 0, nil).
+					WillReturnResult(driver.RowsAffected(2))
+				mock.ExpectExec(single).WithArgs(5, "", 0, nil).
+					WillReturnResult(driver.RowsAffected(1))
+			},
+			wantAffected: 5,
+		},
+		{
+			name:      "batch error",
+			batchSize: 2,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(double).
+					WillReturnResult(driver.RowsAffected(2))
+				mock.ExpectExec(double).
+					WillReturnError(dbErr)
+			},
+			wantErr: dbErr,
+		},
+		{
+			name:      "in tx",
+			batchSize: 3,
+			inTx:      true,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO .*").
+					WillReturnResult(driver.RowsAffected(3))
+				mock.ExpectExec(double).
+					WillReturnResult(driver.RowsAffected(2))
+				mock.ExpectCommit()
+			},
+			wantAffected: 5,
+		},
+		{
+			name:      "in tx rollback",
+			batchSize: 3,
+			inTx:      true,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO .*").
+					WillReturnResult(driver.RowsAffected(3))
+				mock.ExpectExec(double).
+					WillReturnError(dbErr)
+				mock.ExpectRollback()
+			},
+			wantErr: dbErr,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			vals := make([]*TestModel, 0, 5)
+			for id := int64(1); id <= 5; id++ {
+				vals = append(vals, &TestModel{Id: id})
+			}
+			i := NewInserter[TestModel](db).Values(vals...).BatchSize(tc.batchSize)
+			if tc.inTx {
+				i = i.BatchInTx()
+			}
+			res := i.Exec(context.Background())
+			assert.NoError(t, mock.ExpectationsWereMet())
+			// 分批之后 Values 保持不变
+			assert.Len(t, i.values, 5)
+			affected, err := res.RowsAffected()
+			assert.True(t, errors.Is(err, tc.wantErr))
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantAffected, affected)
+		})
+	}
+}
+
+func TestInserter_BatchInOuterTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// 加入外层的事务，不会再开一个事务
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO .*").WillReturnResult(driver.RowsAffected(2))
+	mock.ExpectExec("INSERT INTO .*").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectCommit()
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		res := NewInserter[TestModel](db).Values(&TestModel{Id: 1}, &TestModel{Id: 2}, &TestModel{Id: 3}).
+			BatchSize(2).BatchInTx().Exec(ctx)
+		affected, err := res.RowsAffected()
+		assert.Equal(t, int64(3), affected)
+		return err
+	}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
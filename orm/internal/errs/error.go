@@ -12,6 +12,36 @@ var (
 
 	ErrNoRows        = errors.New("orm: 没有数据")
 	ErrInsertZeroRow = errors.New("orm: 插入 0 行")
+
+	ErrNoUpdatedColumns = errors.New("orm: 未指定更新的列")
+	// ErrNoUpdatedEntity Set(C("Age")) 需要从实体里面读取值
+	ErrNoUpdatedEntity = errors.New("orm: 使用 Column 更新必须调用 Update 指定实体")
+
+	ErrNoConflictColumns = errors.New("orm: ON CONFLICT 必须指定冲突列")
+
+	ErrEmptyValues = errors.New("orm: IN 的值列表不能为空")
+
+	// ErrOptimisticLockConflict 带版本列的更新没有影响任何行
+	// 说明数据已经被别人修改过，或者数据不存在
+	ErrOptimisticLockConflict = errors.New("orm: 乐观锁冲突，数据已经被修改")
+	// ErrUpdateVersion 版本号由 ORM 自己加一，用户不能更新
+	ErrUpdateVersion = errors.New("orm: 不能手动更新版本号列")
+
+	// 数据库返回的错误，由 Dialect 根据错误码翻译
+	// 原始的驱动错误依旧可以通过 errors.As 拿到
+	ErrDuplicateKey        = errors.New("orm: 违反唯一约束")
+	ErrForeignKeyViolation = errors.New("orm: 违反外键约束")
+	ErrDeadlock            = errors.New("orm: 死锁")
+	ErrLockWaitTimeout     = errors.New("orm: 等待锁超时")
+	// ErrSerializationFailure 可串行化隔离级别下，事务之间冲突
+	ErrSerializationFailure = errors.New("orm: 事务序列化失败")
+
+	ErrTxExists = errors.New("orm: 已经在事务里面，不能使用 PropagationNever")
+
+	ErrShardingDirectSQL = errors.New("orm: 分库分表只支持通过 Selector 和 Inserter 执行")
+	// ErrMultiLastInsertId 数据分成多条语句插入，例如分批或者插入了多个分片，
+	// 每条语句都有自己的自增 ID
+	ErrMultiLastInsertId = errors.New("orm: 数据分成多条语句插入，无法确定 LastInsertId")
 )
 
 // func NewErrUnsupportedExpressionV1(expr any) error {
@@ -35,6 +65,10 @@ func NewErrFailedToRollbackTx(bizErr error, rbErr error, panicked bool) error {
 	// 	"是否 panic: %t", bizErr, rbErr, panicked)
 }
 
+func NewErrUnsupportedPropagation(p any) error {
+	return fmt.Errorf("orm: 不支持的事务传播方式 %v", p)
+}
+
 func NewErrUnknownField(name string) error {
 	return fmt.Errorf("orm: 未知字段 %s", name)
 }
@@ -47,6 +81,90 @@ func NewErrInvalidTagContent(pair string) error {
 	return fmt.Errorf("orm: 非法标签值 %s", pair)
 }
 
+func NewErrDuplicateField(name string) error {
+	return fmt.Errorf("orm: 组合的结构体里面有同名字段 %s", name)
+}
+
+func NewErrInvalidRelationType(name string, typ any) error {
+	return fmt.Errorf("orm: 关联字段 %s 的类型 %v 不合法，"+
+		"has_one 和 belongs_to 只支持 *T，has_many 和 many_to_many 只支持 []*T", name, typ)
+}
+
+func NewErrUnknownRelation(name string) error {
+	return fmt.Errorf("orm: 未知关联 %s", name)
+}
+
+func NewErrNoPrimaryKey(table string) error {
+	return fmt.Errorf("orm: %s 没有单一主键，无法关联查询", table)
+}
+
+func NewErrMultipleAutoIncrement(first, second string) error {
+	return fmt.Errorf("orm: 只能有一个自增列，但是 %s 和 %s 都声明了 auto_increment", first, second)
+}
+
+func NewErrMultipleSoftDelete(first, second string) error {
+	return fmt.Errorf("orm: 只能有一个软删除列，但是 %s 和 %s 都声明了 soft_delete", first, second)
+}
+
+func NewErrMultipleVersion(first, second string) error {
+	return fmt.Errorf("orm: 只能有一个版本列，但是 %s 和 %s 都声明了 version", first, second)
+}
+
+func NewErrInvalidVersionType(name string, typ any) error {
+	return fmt.Errorf("orm: 版本列 %s 必须是整数，但是它的类型是 %v", name, typ)
+}
+
+func NewErrUnsupportedColumnType(name string, typ any) error {
+	return fmt.Errorf("orm: 无法推断字段 %s 的列类型 %v", name, typ)
+}
+
+func NewErrNotShardingTable(table string) error {
+	return fmt.Errorf("orm: %s 没有声明分库分表规则", table)
+}
+
+func NewErrUnsupportedShardingKey(key any) error {
+	return fmt.Errorf("orm: 不支持的分片键类型 %T", key)
+}
+
+func NewErrNoShardingRange(key any) error {
+	return fmt.Errorf("orm: 分片键 %v 不在任何范围内", key)
+}
+
+func NewErrUnknownShardingDB(name string) error {
+	return fmt.Errorf("orm: 未知的分库 %s", name)
+}
+
 func NewErrUnsupportedAssignable(expr any) error {
 	return fmt.Errorf("orm: 不支持的赋值表达式类型 %v", expr)
 }
+
+func NewErrUnsupportedScanSrc(src any) error {
+	return fmt.Errorf("orm: 不支持扫描的数据类型 %T", src)
+}
+
+func NewErrUnsupportedResult(res any) error {
+	return fmt.Errorf("orm: 不支持的查询结果类型 %T", res)
+}
+
+// NewErrDB 把驱动返回的错误 err 归类为 sentinel
+// errors.Is 可以判断 sentinel，errors.As 可以拿到驱动的错误
+func NewErrDB(sentinel error, err error) error {
+	return &dbError{sentinel: sentinel, err: err}
+}
+
+type dbError struct {
+	sentinel error
+	err      error
+}
+
+func (e *dbError) Error() string {
+	return e.sentinel.Error() + ": " + e.err.Error()
+}
+
+func (e *dbError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *dbError) Unwrap() error {
+	return e.err
+}
@@ -3,7 +3,10 @@
 package integration
 
 import (
+	"context"
+
 	"github.com/jackycsl/geektime-go-practical/orm"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/test"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -20,5 +23,8 @@ func (s *Suite) SetupSuite() {
 	db, err := orm.Open(s.driver, s.dsn)
 	require.NoError(s.T(), err)
 	db.Wait()
+	// 表不存在的时候根据模型建表，script/mysql 里面的脚本只需要建库
+	err = orm.NewMigrator(db, &test.SimpleStruct{}).Migrate(context.Background())
+	require.NoError(s.T(), err)
 	s.db = db
 }
@@ -30,11 +30,16 @@ func (r reflectValue) Field(name string) (any, error) {
 	// if !ok {
 	// 	// 报错
 	// }
-	val := r.val.FieldByName(name)
-	// if val == (reflect.Value{}) {
-	// 	// 报错
-	// }
-	return val.Interface(), nil
+	fd, ok := r.model.FieldMap[name]
+	if !ok {
+		return nil, errs.NewErrUnknownField(name)
+	}
+	val := fieldByIndex(r.val, fd.Index, false)
+	// 组合的指针是 nil
+	if !val.IsValid() {
+		return reflect.Zero(fd.Type).Interface(), nil
+	}
+	return fieldValue(val), nil
 }
 
 func (r reflectValue) SetColumns(rows *sql.Rows) error {
@@ -84,9 +89,28 @@ func (r reflectValue) SetColumns(rows *sql.Rows) error {
 			return errs.NewErrUnknownColumn(c)
 		}
 		if fd.ColName == c {
-			tpValueElem.FieldByName(fd.GoName).Set(valElem[i])
+			fieldByIndex(tpValueElem, fd.Index, true).Set(valElem[i])
 		}
 	}
 
 	return nil
 }
+
+// fieldByIndex 和 reflect.Value.FieldByIndex 类似
+// 区别在于路径上遇到 nil 指针的时候，alloc 为 true 就初始化，
+// 否则返回 reflect.Value{}，而不是 panic
+func fieldByIndex(val reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(idx)
+	}
+	return val
+}
@@ -2,9 +2,12 @@ package valuer
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
 	"github.com/jackycsl/geektime-go-practical/orm/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,6 +17,10 @@ func Test_reflectValue_SetColumns(t *testing.T) {
 	testSetColumns(t, NewReflectValue)
 }
 
+func Test_reflectValue_Field(t *testing.T) {
+	testField(t, NewReflectValue)
+}
+
 func testSetColumns(t *testing.T, creator Creator) {
 	testCases := []struct {
 		name string
@@ -69,6 +76,52 @@ func testSetColumns(t *testing.T, creator Creator) {
 				LastName: &sql.NullString{Valid: true, String: "Jerry"},
 			},
 		},
+		{
+			name:   "embedded",
+			entity: &EmbeddedModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "first_name", "create_time"})
+				rows.AddRow("1", "Tom", "1000")
+				return rows
+			},
+			wantEntity: &EmbeddedModel{
+				BaseModel: BaseModel{
+					Id:         1,
+					CreateTime: 1000,
+				},
+				FirstName: "Tom",
+			},
+		},
+		{
+			name:   "scanner",
+			entity: &CustomModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "custom"})
+				rows.AddRow("1", "Tom")
+				return rows
+			},
+			wantEntity: &CustomModel{
+				Id:     1,
+				Custom: ptrValuer{Val: "Tom"},
+			},
+		},
+		{
+			// 嵌入指针会被自动初始化
+			name:   "embedded pointer",
+			entity: &EmbeddedPtrModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "first_name", "create_time"})
+				rows.AddRow("1", "Tom", "1000")
+				return rows
+			},
+			wantEntity: &EmbeddedPtrModel{
+				BaseModel: &BaseModel{
+					Id:         1,
+					CreateTime: 1000,
+				},
+				FirstName: "Tom",
+			},
+		},
 	}
 
 	r := model.NewRegistry()
@@ -100,6 +153,75 @@ func testSetColumns(t *testing.T, creator Creator) {
 	}
 }
 
+func testField(t *testing.T, creator Creator) {
+	testCases := []struct {
+		name   string
+		entity any
+		field  string
+
+		wantVal any
+		wantErr error
+	}{
+		{
+			name:    "normal",
+			entity:  &TestModel{Id: 12},
+			field:   "Id",
+			wantVal: int64(12),
+		},
+		{
+			name:    "invalid field",
+			entity:  &TestModel{},
+			field:   "Invalid",
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name:    "embedded",
+			entity:  &EmbeddedModel{BaseModel: BaseModel{CreateTime: 1000}},
+			field:   "CreateTime",
+			wantVal: int64(1000),
+		},
+		{
+			name:    "embedded pointer",
+			entity:  &EmbeddedPtrModel{BaseModel: &BaseModel{CreateTime: 1000}},
+			field:   "CreateTime",
+			wantVal: int64(1000),
+		},
+		{
+			// 只有指针实现了 driver.Valuer 的时候返回指针
+			name:    "pointer valuer",
+			entity:  &CustomModel{Custom: ptrValuer{Val: "Tom"}},
+			field:   "Custom",
+			wantVal: &ptrValuer{Val: "Tom"},
+		},
+		{
+			name:    "valuer",
+			entity:  &TestModel{LastName: &sql.NullString{Valid: true, String: "Jerry"}},
+			field:   "LastName",
+			wantVal: &sql.NullString{Valid: true, String: "Jerry"},
+		},
+		{
+			// 嵌入指针为 nil 的时候返回零值
+			name:    "nil embedded pointer",
+			entity:  &EmbeddedPtrModel{},
+			field:   "CreateTime",
+			wantVal: int64(0),
+		},
+	}
+	r := model.NewRegistry()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := r.Get(tc.entity)
+			require.NoError(t, err)
+			val, err := creator(m, tc.entity).Field(tc.field)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
 type TestModel struct {
 	Id int64
 	// ""
@@ -107,3 +229,44 @@ type TestModel struct {
 	Age       int8
 	LastName  *sql.NullString
 }
+
+type BaseModel struct {
+	Id         int64
+	CreateTime int64
+}
+
+type EmbeddedModel struct {
+	BaseModel
+	FirstName string
+}
+
+type EmbeddedPtrModel struct {
+	FirstName string
+	*BaseModel
+}
+
+type CustomModel struct {
+	Id     int64
+	Custom ptrValuer
+}
+
+// ptrValuer 只有指针实现了 driver.Valuer 和 sql.Scanner
+type ptrValuer struct {
+	Val string
+}
+
+func (p *ptrValuer) Value() (driver.Value, error) {
+	return p.Val, nil
+}
+
+func (p *ptrValuer) Scan(src any) error {
+	switch val := src.(type) {
+	case string:
+		p.Val = val
+	case []byte:
+		p.Val = string(val)
+	default:
+		return fmt.Errorf("不支持的类型 %T", src)
+	}
+	return nil
+}
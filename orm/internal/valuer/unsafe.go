@@ -13,15 +13,18 @@ type unsafeValue struct {
 	model *model.Model
 	// 起始地址
 	address unsafe.Pointer
+	// 组合了指针的字段没办法通过偏移量访问，只能用反射
+	val reflect.Value
 }
 
 var _ Creator = NewUnsafeValue
 
 func NewUnsafeValue(model *model.Model, val any) Value {
-	address := reflect.ValueOf(val).UnsafePointer()
+	refVal := reflect.ValueOf(val)
 	return unsafeValue{
 		model:   model,
-		address: address,
+		address: refVal.UnsafePointer(),
+		val:     refVal.Elem(),
 	}
 }
 
@@ -30,13 +33,20 @@ func (r unsafeValue) Field(name string) (any, error) {
 	if !ok {
 		return nil, errs.NewErrUnknownField(name)
 	}
+	if fd.ViaPointer {
+		val := fieldByIndex(r.val, fd.Index, false)
+		if !val.IsValid() {
+			return reflect.Zero(fd.Type).Interface(), nil
+		}
+		return fieldValue(val), nil
+	}
 	fdAddress := unsafe.Pointer(uintptr(r.address) + fd.Offset)
 
 	// 反射在特定的地址上，创建一个特定类型的实例
 	// 这里创建的实例是原本类型的指针类型
 	// 例如 fd.Type = int，那么val 是 *int
 	val := reflect.NewAt(fd.Type, fdAddress)
-	return val.Elem().Interface(), nil
+	return fieldValue(val.Elem()), nil
 }
 
 func (r unsafeValue) SetColumns(rows *sql.Rows) error {
@@ -53,6 +63,10 @@ func (r unsafeValue) SetColumns(rows *sql.Rows) error {
 		if !ok {
 			return errs.NewErrUnknownColumn(c)
 		}
+		if fd.ViaPointer {
+			vals = append(vals, fieldByIndex(r.val, fd.Index, true).Addr().Interface())
+			continue
+		}
 		// 是不是要计算字段的地址？
 		// 起始地址 + 偏移量
 		fdAddress := unsafe.Pointer(uintptr(r.address) + fd.Offset)
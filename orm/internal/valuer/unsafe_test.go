@@ -5,3 +5,7 @@ import "testing"
 func Test_unsafeValue_SetColumns(t *testing.T) {
 	testSetColumns(t, NewUnsafeValue)
 }
+
+func Test_unsafeValue_Field(t *testing.T) {
+	testField(t, NewUnsafeValue)
+}
@@ -2,6 +2,8 @@ package valuer
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"reflect"
 
 	"github.com/jackycsl/geektime-go-practical/orm/model"
 )
@@ -13,6 +15,20 @@ type Value interface {
 
 type Creator func(model *model.Model, entity any) Value
 
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// fieldValue 返回字段的值
+// 如果只有指针实现了 driver.Valuer，那么返回指针，
+// 否则驱动拿到的是结构体本身，调用不到 Value 方法
+func fieldValue(val reflect.Value) any {
+	typ := val.Type()
+	if val.CanAddr() && !typ.Implements(valuerType) &&
+		reflect.PointerTo(typ).Implements(valuerType) {
+		return val.Addr().Interface()
+	}
+	return val.Interface()
+}
+
 type ValuerV1 interface {
 	SetColumns(entity any, rows sql.Rows) error
 }
@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+)
+
+// Iterator 逐行读取结果集，不会缓存已经读过的数据
+// 用法和 sql.Rows 类似：
+//
+//	it := NewSelector[User](db).Iter(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		u := it.Value()
+//	}
+//	err := it.Err()
+type Iterator[T any] struct {
+	c    core
+	rows *sql.Rows
+	cur  *T
+	err  error
+
+	// 用于调用 AfterFind
+	ctx context.Context
+	qc  *QueryContext
+}
+
+func newIterator[T any](ctx context.Context, c core, qc *QueryContext, res *QueryResult) *Iterator[T] {
+	if res.Err != nil {
+		return &Iterator[T]{err: res.Err}
+	}
+	rows, ok := res.Result.(*sql.Rows)
+	if !ok {
+		// 说明 middleware 篡改了结果
+		return &Iterator[T]{err: errs.NewErrUnsupportedResult(res.Result)}
+	}
+	return &Iterator[T]{
+		c:    c,
+		rows: rows,
+		ctx:  ctx,
+		qc:   qc,
+	}
+}
+
+// Next 准备下一行数据，没有数据或者出错的时候返回 false
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil || it.rows == nil {
+		return false
+	}
+	if !it.rows.Next() {
+		it.err = it.rows.Err()
+		return false
+	}
+	tp := new(T)
+	val := it.c.creator(it.c.model, tp)
+	if err := val.SetColumns(it.rows); err != nil {
+		it.err = err
+		return false
+	}
+	if err := afterFind(it.ctx, it.qc, tp); err != nil {
+		it.err = err
+		return false
+	}
+	it.cur = tp
+	return true
+}
+
+// Value 返回 Next 准备好的那一行数据
+func (it *Iterator[T]) Value() *T {
+	return it.cur
+}
+
+// Err 返回迭代过程中遇到的错误，包括构造 SQL 和发起查询的错误
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close 关闭结果集，可以重复调用
+func (it *Iterator[T]) Close() error {
+	if it.rows == nil {
+		return nil
+	}
+	return it.rows.Close()
+}
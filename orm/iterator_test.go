@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector_Iter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	var queries []string
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			q, err := qc.Builder.Build()
+			if err == nil {
+				queries = append(queries, q.SQL)
+			}
+			return next(ctx, qc)
+		}
+	}))
+	require.NoError(t, err)
+
+	// 对应于 query error
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("query error"))
+
+	// 对应于 no rows
+	rows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+
+	// data
+	rows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	rows.AddRow("1", "Tom", "18", "Jerry")
+	rows.AddRow("2", "Daming", "19", "Deng")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+
+	// scan error
+	rows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	rows.AddRow("1", "Tom", "18", "Jerry")
+	rows.AddRow("abc", "Daming", "19", "Deng")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows).RowsWillBeClosed()
+
+	testCases := []struct {
+		name string
+		s    *Selector[TestModel]
+
+		wantErr error
+		wantRes []*TestModel
+	}{
+		{
+			name:    "invalid query",
+			s:       NewSelector[TestModel](db).Where(C("XXX").Eq(1)),
+			wantErr: errs.NewErrUnknownField("XXX"),
+		},
+		{
+			name:    "query error",
+			s:       NewSelector[TestModel](db),
+			wantErr: errors.New("query error"),
+		},
+		{
+			name: "no rows",
+			s:    NewSelector[TestModel](db),
+		},
+		{
+			name: "data",
+			s:    NewSelector[TestModel](db),
+			wantRes: []*TestModel{
+				{
+					Id:        1,
+					FirstName: "Tom",
+					Age:       18,
+					LastName:  &sql.NullString{Valid: true, String: "Jerry"},
+				},
+				{
+					Id:        2,
+					FirstName: "Daming",
+					Age:       19,
+					LastName:  &sql.NullString{Valid: true, String: "Deng"},
+				},
+			},
+		},
+		{
+			name: "scan error",
+			s:    NewSelector[TestModel](db),
+			wantRes: []*TestModel{
+				{
+					Id:        1,
+					FirstName: "Tom",
+					Age:       18,
+					LastName:  &sql.NullString{Valid: true, String: "Jerry"},
+				},
+			},
+			wantErr: errors.New("sql: Scan error on column index 0, name \"id\": " +
+				"converting driver.Value type string (\"abc\") to a int64: invalid syntax"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			it := tc.s.Iter(context.Background())
+			var res []*TestModel
+			for it.Next() {
+				res = append(res, it.Value())
+			}
+			require.NoError(t, it.Close())
+			assert.Equal(t, tc.wantRes, res)
+			if tc.wantErr != nil {
+				require.Error(t, it.Err())
+				assert.Equal(t, tc.wantErr.Error(), it.Err().Error())
+				return
+			}
+			assert.NoError(t, it.Err())
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+	// 除了构造失败的，每一个查询都经过了 middleware
+	assert.Equal(t, 4, len(queries))
+}
@@ -0,0 +1,50 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+)
+
+// JsonColumn 代表存储为 JSON 文本的列
+// Valid 为 false 的时候对应于数据库里面的 NULL
+type JsonColumn[T any] struct {
+	Val   T
+	Valid bool
+}
+
+// Value 参考 sql.NullXXX 类型定义的
+// 返回 string 而不是 []byte，避免部分驱动把 []byte 当成二进制处理
+func (j JsonColumn[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+	bs, err := json.Marshal(j.Val)
+	if err != nil {
+		return nil, err
+	}
+	return string(bs), nil
+}
+
+func (j *JsonColumn[T]) Scan(src any) error {
+	var bs []byte
+	switch val := src.(type) {
+	case nil:
+		// NULL 要把之前的数据清空
+		var t T
+		j.Val, j.Valid = t, false
+		return nil
+	case string:
+		bs = []byte(val)
+	case []byte:
+		bs = val
+	default:
+		return errs.NewErrUnsupportedScanSrc(src)
+	}
+	if err := json.Unmarshal(bs, &j.Val); err != nil {
+		return err
+	}
+	j.Valid = true
+	return nil
+}
@@ -0,0 +1,146 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonColumn_Value(t *testing.T) {
+	testCases := []struct {
+		name    string
+		val     JsonColumn[Settings]
+		wantVal driver.Value
+		wantErr error
+	}{
+		{
+			name: "invalid",
+			val:  JsonColumn[Settings]{Val: Settings{Theme: "dark"}},
+		},
+		{
+			name:    "valid",
+			val:     JsonColumn[Settings]{Val: Settings{Theme: "dark", Size: 12}, Valid: true},
+			wantVal: `{"theme":"dark","size":12}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, err := tc.val.Value()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestJsonColumn_Scan(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     any
+		wantVal JsonColumn[Settings]
+		wantErr error
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:    "string",
+			src:     `{"theme":"dark","size":12}`,
+			wantVal: JsonColumn[Settings]{Val: Settings{Theme: "dark", Size: 12}, Valid: true},
+		},
+		{
+			name:    "bytes",
+			src:     []byte(`{"theme":"dark","size":12}`),
+			wantVal: JsonColumn[Settings]{Val: Settings{Theme: "dark", Size: 12}, Valid: true},
+		},
+		{
+			name:    "invalid type",
+			src:     12,
+			wantErr: errs.NewErrUnsupportedScanSrc(12),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var val JsonColumn[Settings]
+			err := val.Scan(tc.src)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestJsonColumn_Get(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []DBOption
+	}{
+		{
+			name: "unsafe",
+		},
+		{
+			name: "reflect",
+			opts: []DBOption{DBUseReflect()},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB, tc.opts...)
+			require.NoError(t, err)
+
+			rows := sqlmock.NewRows([]string{"id", "settings", "extra"})
+			rows.AddRow("1", `{"theme":"dark","size":12}`, nil)
+			mock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+			res, err := NewSelector[JsonModel](db).Get(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, &JsonModel{
+				Id:       1,
+				Settings: JsonColumn[Settings]{Val: Settings{Theme: "dark", Size: 12}, Valid: true},
+			}, res)
+		})
+	}
+}
+
+func TestJsonColumn_Insert(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT .*").
+		WithArgs(int64(1), `{"theme":"dark","size":12}`, nil).
+		WillReturnResult(driver.RowsAffected(1))
+	res := NewInserter[JsonModel](db).Values(&JsonModel{
+		Id:       1,
+		Settings: JsonColumn[Settings]{Val: Settings{Theme: "dark", Size: 12}, Valid: true},
+	}).Exec(context.Background())
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type Settings struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+type JsonModel struct {
+	Id       int64
+	Settings JsonColumn[Settings]
+	Extra    *JsonColumn[Settings]
+}
@@ -2,6 +2,7 @@ package orm
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/jackycsl/geektime-go-practical/orm/model"
 )
@@ -14,6 +15,21 @@ type QueryContext struct {
 	Builder QueryBuilder
 
 	Model *model.Model
+
+	// ResultType 查询结果 QueryResult.Result 的类型，*T 或者 []*T
+	// 只有 Get 和 GetMulti 会设置，Iter 和 Exec 都是 nil
+	ResultType reflect.Type
+
+	// HasWhere 用户是否指定了 WHERE 条件，只有 UPDATE 和 DELETE 会设置
+	// ORM 自己加上的条件不算，例如软删除的 IS NULL 和乐观锁的版本号
+	HasWhere bool
+
+	// Tx 执行查询的事务，不在事务里面是 nil
+	Tx *Tx
+
+	// Tables 查询涉及的所有表，包括 JOIN 和子查询里面的表
+	// 只有 SELECT 和 DELETE 会设置，没有设置的时候就是 Model 对应的表
+	Tables []string
 }
 
 type QueryResult struct {
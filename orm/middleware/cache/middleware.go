@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	ecache "github.com/jackycsl/geektime-go-practical/cache"
+	"github.com/jackycsl/geektime-go-practical/orm"
+)
+
+type cacheKey struct{}
+
+// WithCache 标记这个查询的结果需要缓存，只对 Get 和 GetMulti 生效
+// expiration 为 0 的时候使用 MiddlewareBuilder 的过期时间
+func WithCache(ctx context.Context, expiration time.Duration) context.Context {
+	return context.WithValue(ctx, cacheKey{}, expiration)
+}
+
+// MiddlewareBuilder 缓存查询结果
+// 结果用 JSON 序列化，所以实体里面未导出的字段不会被缓存，缓存命中的时候也不会执行 AfterFind
+// 每张表有一个版本号，缓存的 key 里面带上查询涉及的所有表的版本号
+// 任何非 SELECT 的语句经过的时候就更新版本号，旧的缓存等过期之后自然淘汰
+// 事务里面的写操作等到提交之后才更新版本号，不然提交之前别的查询会把旧数据缓存到新版本号下面
+// 事务里面的查询可能读到还没有提交的数据，所以不走缓存
+type MiddlewareBuilder struct {
+	cache      ecache.Cache
+	expiration time.Duration
+	prefix     string
+}
+
+func NewMiddlewareBuilder(c ecache.Cache, expiration time.Duration) *MiddlewareBuilder {
+	return &MiddlewareBuilder{
+		cache:      c,
+		expiration: expiration,
+		prefix:     "orm",
+	}
+}
+
+// Prefix 缓存 key 的前缀，默认是 orm
+func (m *MiddlewareBuilder) Prefix(prefix string) *MiddlewareBuilder {
+	m.prefix = prefix
+	return m
+}
+
+func (m MiddlewareBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			if qc.Model == nil {
+				return next(ctx, qc)
+			}
+			tables := qc.Tables
+			if len(tables) == 0 {
+				tables = []string{qc.Model.TableName}
+			}
+			// 有 ResultType 的 RAW 查询也是读
+			if qc.Type != "SELECT" && qc.ResultType == nil {
+				res := next(ctx, qc)
+				if qc.Tx != nil {
+					qc.Tx.OnCommit(func(ctx context.Context) {
+						_ = m.invalidate(ctx, tables)
+					})
+					return res
+				}
+				// 失败了也不知道数据有没有被修改，所以一律失效
+				_ = m.invalidate(ctx, tables)
+				return res
+			}
+			expiration, ok := ctx.Value(cacheKey{}).(time.Duration)
+			// 事务里面可能读到还没有提交的数据，不能缓存
+			if !ok || qc.Type != "SELECT" || qc.ResultType == nil || qc.Tx != nil {
+				return next(ctx, qc)
+			}
+			if expiration == 0 {
+				expiration = m.expiration
+			}
+
+			key, err := m.key(ctx, qc, tables)
+			if err != nil {
+				return next(ctx, qc)
+			}
+			if res, ok := m.get(ctx, key, qc.ResultType); ok {
+				return &orm.QueryResult{Result: res}
+			}
+			res := next(ctx, qc)
+			if res.Err != nil {
+				return res
+			}
+			if data, err := json.Marshal(res.Result); err == nil {
+				_ = m.cache.Set(ctx, key, data, expiration)
+			}
+			return res
+		}
+	}
+}
+
+func (m MiddlewareBuilder) get(ctx context.Context, key string, typ reflect.Type) (any, bool) {
+	val, err := m.cache.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	var data []byte
+	switch v := val.(type) {
+	case []byte:
+		data = v
+	case string:
+		// Redis 返回的是字符串
+		data = []byte(v)
+	default:
+		return nil, false
+	}
+	res := reflect.New(typ)
+	if err = json.Unmarshal(data, res.Interface()); err != nil {
+		return nil, false
+	}
+	return res.Elem().Interface(), true
+}
+
+// key 由涉及的表名、表的版本号、SQL 和参数组成
+// 任何一张表被修改了，key 都会变
+func (m MiddlewareBuilder) key(ctx context.Context, qc *orm.QueryContext, tables []string) (string, error) {
+	q, err := qc.Builder.Build()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(m.prefix)
+	for _, table := range tables {
+		version, err := m.version(ctx, table)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(":" + table + ":" + version)
+	}
+	args, err := json.Marshal(q.Args)
+	if err != nil {
+		return "", err
+	}
+	h := sha1.New()
+	h.Write([]byte(q.SQL))
+	h.Write(args)
+	// Get 和 GetMulti 的 SQL 是一样的，但是结果的类型不同
+	h.Write([]byte(qc.ResultType.String()))
+	sb.WriteString(":" + hex.EncodeToString(h.Sum(nil)))
+	return sb.String(), nil
+}
+
+func (m MiddlewareBuilder) versionKey(table string) string {
+	return m.prefix + ":version:" + table
+}
+
+func (m MiddlewareBuilder) version(ctx context.Context, table string) (string, error) {
+	val, err := m.cache.Get(ctx, m.versionKey(table))
+	if err == nil {
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		}
+	}
+	// 版本号不存在，可能是被淘汰了，生成一个新的
+	// 不能从 0 开始，不然会命中淘汰之前的缓存
+	version := newVersion()
+	return version, m.cache.Set(ctx, m.versionKey(table), version, 0)
+}
+
+// invalidate 更新所有表的版本号，某张表失败了也要继续，返回第一个错误
+func (m MiddlewareBuilder) invalidate(ctx context.Context, tables []string) error {
+	var err error
+	for _, table := range tables {
+		if e := m.cache.Set(ctx, m.versionKey(table), newVersion(), 0); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func newVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
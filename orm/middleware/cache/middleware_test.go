@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	ecache "github.com/jackycsl/geektime-go-practical/cache"
+	"github.com/jackycsl/geektime-go-practical/orm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache func() ecache.Cache
+	}{
+		{
+			name: "local",
+			cache: func() ecache.Cache {
+				return ecache.NewBuildInMapCache(time.Minute)
+			},
+		},
+		{
+			name: "string value",
+			cache: func() ecache.Cache {
+				return &stringCache{Cache: ecache.NewBuildInMapCache(time.Minute)}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			m := NewMiddlewareBuilder(tc.cache(), time.Minute)
+			db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(m.Build()))
+			require.NoError(t, err)
+
+			ctx := WithCache(context.Background(), 0)
+			query := regexp.QuoteMeta("SELECT * FROM `test_model` WHERE `id` = ?;")
+			rows := func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"}).
+					AddRow(1, "Tom", 18, "Jerry")
+			}
+			want := &TestModel{
+				Id:        1,
+				FirstName: "Tom",
+				Age:       18,
+				LastName:  &sql.NullString{String: "Jerry", Valid: true},
+			}
+
+			// 只有第一次查询数据库
+			mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows())
+			for i := 0; i < 2; i++ {
+				res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(1)).Get(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, want, res)
+			}
+
+			// GetMulti 的结果类型不一样，单独缓存
+			mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows())
+			for i := 0; i < 2; i++ {
+				res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(1)).GetMulti(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, []*TestModel{want}, res)
+			}
+
+			// 没有标记的查询不走缓存
+			mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows())
+			_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(1)).
+				Get(context.Background())
+			require.NoError(t, err)
+
+			// 写操作之后缓存失效
+			mock.ExpectExec("INSERT INTO .*").WillReturnResult(driver.RowsAffected(1))
+			err = orm.NewInserter[TestModel](db).Values(&TestModel{Id: 2}).
+				Exec(context.Background()).Err()
+			require.NoError(t, err)
+			mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows())
+			for i := 0; i < 2; i++ {
+				_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(1)).Get(ctx)
+				require.NoError(t, err)
+			}
+
+			// 没有数据不缓存
+			mock.ExpectQuery(query).WithArgs(3).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectQuery(query).WithArgs(3).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			for i := 0; i < 2; i++ {
+				_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(3)).Get(ctx)
+				assert.Equal(t, orm.ErrNoRows, err)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMiddlewareBuilder_Tx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	m := NewMiddlewareBuilder(ecache.NewBuildInMapCache(time.Minute), time.Minute)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(m.Build()))
+	require.NoError(t, err)
+
+	ctx := WithCache(context.Background(), 0)
+	query := regexp.QuoteMeta("SELECT * FROM `test_model` WHERE `id` = ?;")
+	rows := func(name string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name"}).AddRow(2, name)
+	}
+
+	// 事务里面读到的是还没有提交的数据，回滚之后别人不能读到
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO .*").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectQuery(query).WithArgs(2).WillReturnRows(rows("uncommitted"))
+	mock.ExpectRollback()
+	mock.ExpectQuery(query).WithArgs(2).WillReturnRows(rows("Tom"))
+	err = db.DoTx(ctx, func(ctx context.Context, tx *orm.Tx) error {
+		err := orm.NewInserter[TestModel](tx).Values(&TestModel{Id: 2, FirstName: "uncommitted"}).
+			Exec(ctx).Err()
+		require.NoError(t, err)
+		res, err := orm.NewSelector[TestModel](tx).Where(orm.C("Id").Eq(2)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "uncommitted", res.FirstName)
+		return errors.New("rollback")
+	}, nil)
+	require.Error(t, err)
+
+	res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(2)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Tom", res.FirstName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_TxCommit(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	m := NewMiddlewareBuilder(ecache.NewBuildInMapCache(time.Minute), time.Minute)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(m.Build()))
+	require.NoError(t, err)
+
+	ctx := WithCache(context.Background(), 0)
+	query := regexp.QuoteMeta("SELECT * FROM `test_model` WHERE `id` = ?;")
+	rows := func(name string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, name)
+	}
+	get := func() string {
+		res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").Eq(1)).Get(ctx)
+		require.NoError(t, err)
+		return res.FirstName
+	}
+
+	mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows("Tom"))
+	assert.Equal(t, "Tom", get())
+
+	// 事务里面修改了数据，提交之前别的查询读到的还是旧数据
+	// 这个时候不能让旧数据缓存到新的版本号下面
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectCommit()
+	err = db.DoTx(ctx, func(txCtx context.Context, tx *orm.Tx) error {
+		err := orm.NewUpdater[TestModel](tx).Update(&TestModel{FirstName: "Jerry"}).
+			Set(orm.C("FirstName")).Where(orm.C("Id").Eq(1)).Exec(txCtx).Err()
+		require.NoError(t, err)
+		assert.Equal(t, "Tom", get())
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	// 提交之后缓存失效
+	mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows("Jerry"))
+	assert.Equal(t, "Jerry", get())
+	assert.Equal(t, "Jerry", get())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMiddlewareBuilder_Tables(t *testing.T) {
+	testCases := []struct {
+		name   string
+		write  func(db *orm.DB) error
+		mock   func(mock sqlmock.Sqlmock)
+		reload bool
+	}{
+		{
+			name: "insert joined table",
+			write: func(db *orm.DB) error {
+				return orm.NewInserter[TestOrder](db).Values(&TestOrder{Id: 1}).
+					Exec(context.Background()).Err()
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `test_order`.*").WillReturnResult(driver.RowsAffected(1))
+			},
+			reload: true,
+		},
+		{
+			name: "insert subquery table",
+			write: func(db *orm.DB) error {
+				return orm.NewInserter[TestOther](db).Values(&TestOther{Id: 1}).
+					Exec(context.Background()).Err()
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `test_other`.*").WillReturnResult(driver.RowsAffected(1))
+			},
+			reload: true,
+		},
+		{
+			name: "unrelated table",
+			write: func(db *orm.DB) error {
+				return orm.NewInserter[TestLog](db).Values(&TestLog{Id: 1}).
+					Exec(context.Background()).Err()
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `test_log`.*").WillReturnResult(driver.RowsAffected(1))
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			m := NewMiddlewareBuilder(ecache.NewBuildInMapCache(time.Minute), time.Minute)
+			db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(m.Build()))
+			require.NoError(t, err)
+
+			ctx := WithCache(context.Background(), 0)
+			t1, t2 := orm.TableOf(&TestModel{}), orm.TableOf(&TestOrder{})
+			get := func() {
+				// 子查询里面的表也算
+				_, err := orm.NewSelector[TestModel](db).
+					From(t1.Join(t2).On(t1.C("Id").Eq(t2.C("UserId")))).
+					Where(t1.C("Id").In(orm.NewSelector[TestOther](db).Select(orm.C("Id")).AsSubquery())).
+					Get(ctx)
+				require.NoError(t, err)
+			}
+			rows := func() *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id"}).AddRow(1)
+			}
+
+			mock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+			get()
+			tc.mock(mock)
+			require.NoError(t, tc.write(db))
+			if tc.reload {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+			}
+			get()
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMiddlewareBuilder_DeleteFrom(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	m := NewMiddlewareBuilder(ecache.NewBuildInMapCache(time.Minute), time.Minute)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(m.Build()))
+	require.NoError(t, err)
+
+	ctx := WithCache(context.Background(), 0)
+	get := func() {
+		_, err := orm.NewSelector[TestOrder](db).Get(ctx)
+		require.NoError(t, err)
+	}
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id"}).AddRow(1)
+	}
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	get()
+	// 失效的是 From 指定的表，而不是 T 对应的表
+	mock.ExpectExec("DELETE FROM `test_order`.*").WillReturnResult(driver.RowsAffected(1))
+	err = orm.NewDeleter[TestModel](db).From(orm.TableOf(&TestOrder{})).
+		Where(orm.C("Id").Eq(1)).Exec(context.Background()).Err()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows())
+	get()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// stringCache 模拟 Redis，取出来的都是字符串
+type stringCache struct {
+	ecache.Cache
+}
+
+func (s *stringCache) Get(ctx context.Context, key string) (any, error) {
+	val, err := s.Cache.Get(ctx, key)
+	if data, ok := val.([]byte); ok {
+		return string(data), err
+	}
+	return val, err
+}
+
+type TestOrder struct {
+	Id     int64
+	UserId int64
+}
+
+type TestOther struct {
+	Id int64
+}
+
+type TestLog struct {
+	Id int64
+}
+
+type TestModel struct {
+	Id        int64
+	FirstName string
+	Age       int8
+	LastName  *sql.NullString
+}
@@ -21,7 +21,7 @@ func (m MiddlewareBuilder) Build() orm.Middleware {
 		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
 			if qc.Type == "DELETE" {
 				return &orm.QueryResult{
-					Err: errors.New("禁止 Delete 语句"),
+					Err: errors.New("禁止 Delete 语句，请使用软删除"),
 				}
 			}
 			return next(ctx, qc)
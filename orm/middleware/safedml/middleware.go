@@ -9,6 +9,8 @@ import (
 	"github.com/jackycsl/geektime-go-practical/orm"
 )
 
+var errNoWhere = errors.New("不准执行没有 WHERE 的 delete 或者 update 语句")
+
 // 要强制查询语句
 // 1. SELECT、update、delete 必须要带 WHERE
 // 2. update 和 delete 必须要带 WHERE
@@ -22,7 +24,17 @@ func NewMiddlewareBuilder() *MiddlewareBuilder {
 func (m MiddlewareBuilder) Build() orm.Middleware {
 	return func(next orm.Handler) orm.Handler {
 		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
-			if qc.Type == "SELECT" || qc.Type == "INSERT" {
+			switch qc.Type {
+			// DDL 没有 WHERE，例如 Migrator 的 CREATE TABLE
+			case "SELECT", "INSERT", "DDL":
+				return next(ctx, qc)
+			case "UPDATE", "DELETE":
+				// 软删除和乐观锁会自己加上 WHERE，所以不能看 SQL
+				if !qc.HasWhere {
+					return &orm.QueryResult{
+						Err: errNoWhere,
+					}
+				}
 				return next(ctx, qc)
 			}
 			q, err := qc.Builder.Build()
@@ -31,9 +43,9 @@ func (m MiddlewareBuilder) Build() orm.Middleware {
 					Err: err,
 				}
 			}
-			if strings.Contains(q.SQL, "WHERE") {
+			if !strings.Contains(q.SQL, "WHERE") {
 				return &orm.QueryResult{
-					Err: errors.New("不准执行没有 WHERE 的 delete 或者 update 语句"),
+					Err: errNoWhere,
 				}
 			}
 			return next(ctx, qc)
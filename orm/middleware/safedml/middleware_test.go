@@ -0,0 +1,129 @@
+package querylog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(mock sqlmock.Sqlmock)
+		exec func(db *orm.DB) orm.Result
+
+		wantErr error
+	}{
+		{
+			name: "delete with where",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewDeleter[TestModel](db).Where(orm.C("Id").Eq(1)).Exec(context.Background())
+			},
+		},
+		{
+			name: "delete without where",
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewDeleter[TestModel](db).Exec(context.Background())
+			},
+			wantErr: errNoWhere,
+		},
+		{
+			name: "update with where",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewUpdater[TestModel](db).Set(orm.Assign("Age", 18)).
+					Where(orm.C("Id").Eq(1)).Exec(context.Background())
+			},
+		},
+		{
+			name: "update without where",
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewUpdater[TestModel](db).Set(orm.Assign("Age", 18)).Exec(context.Background())
+			},
+			wantErr: errNoWhere,
+		},
+		{
+			// 软删除会加上 deleted_at IS NULL，但是依旧会更新所有的行
+			name: "soft delete without where",
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewDeleter[SoftDeleteModel](db).Exec(context.Background())
+			},
+			wantErr: errNoWhere,
+		},
+		{
+			name: "soft delete with where",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewDeleter[SoftDeleteModel](db).Where(orm.C("Id").Eq(1)).Exec(context.Background())
+			},
+		},
+		{
+			name: "insert",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			exec: func(db *orm.DB) orm.Result {
+				return orm.NewInserter[TestModel](db).Values(&TestModel{Id: 1}).Exec(context.Background())
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := orm.OpenDB(mockDB, orm.DBWithMiddlewares(NewMiddlewareBuilder().Build()))
+			require.NoError(t, err)
+			if tc.mock != nil {
+				tc.mock(mock)
+			}
+			err = tc.exec(db).Err()
+			assert.Equal(t, tc.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMiddlewareBuilder_Migrate(t *testing.T) {
+	db, err := orm.Open("sqlite3", "file:safedml_migrate.db?cache=shared&mode=memory",
+		orm.DBWithDialect(orm.DialectSQLite),
+		orm.DBWithMiddlewares(NewMiddlewareBuilder().Build()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// CREATE TABLE 和 CREATE INDEX 都没有 WHERE，也要放行
+	err = orm.NewMigrator(db, &MigrateModel{}).Migrate(context.Background())
+	require.NoError(t, err)
+	err = orm.NewInserter[MigrateModel](db).Values(&MigrateModel{Name: "Tom"}).
+		Exec(context.Background()).Err()
+	assert.NoError(t, err)
+}
+
+type MigrateModel struct {
+	Id   int64  `orm:"pk,auto_increment"`
+	Name string `orm:"index"`
+}
+
+type TestModel struct {
+	Id   int64
+	Name string
+	Age  int8
+}
+
+type SoftDeleteModel struct {
+	Id        int64
+	DeletedAt *time.Time `orm:"soft_delete"`
+}
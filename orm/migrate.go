@@ -0,0 +1,177 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/jackycsl/geektime-go-practical/orm/model"
+)
+
+// Migrator 根据模型的元数据生成 DDL，并且和数据库里面的表结构比较
+// 只做加法：新建表、新增列和新增索引
+// 不会删除或者修改已有的列和索引，这些需要人工处理
+type Migrator struct {
+	core
+	sess     Session
+	entities []any
+}
+
+// NewMigrator entities 是需要迁移的模型，必须是结构体指针
+//
+//	err := NewMigrator(db, &User{}, &Order{}).Migrate(ctx)
+func NewMigrator(sess Session, entities ...any) *Migrator {
+	return &Migrator{
+		core:     sess.getCore(),
+		sess:     sess,
+		entities: entities,
+	}
+}
+
+// CreateTable 返回建表语句，以及建索引的语句
+func (m *Migrator) CreateTable(entity any) ([]string, error) {
+	mdl, err := m.r.Get(entity)
+	if err != nil {
+		return nil, err
+	}
+	b := m.newBuilder(mdl)
+	table, err := b.buildCreateTable(mdl)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(mdl.Indexes)+1)
+	res = append(res, table)
+	for _, idx := range mdl.Indexes {
+		res = append(res, b.buildCreateIndex(mdl, idx))
+	}
+	return res, nil
+}
+
+// Diff 返回让数据库和模型保持一致需要执行的语句
+// 表不存在就建表，否则只补上缺少的列和索引
+func (m *Migrator) Diff(ctx context.Context) ([]string, error) {
+	stmts, err := m.diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		res = append(res, stmt.sql)
+	}
+	return res, nil
+}
+
+// Migrate 执行 Diff 返回的语句
+// 大多数数据库的 DDL 不支持事务，中途失败的时候，已经执行的语句不会回滚，
+// 再执行一次 Migrate 就可以从失败的地方继续
+func (m *Migrator) Migrate(ctx context.Context) error {
+	stmts, err := m.diff(ctx)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		res := exec(ctx, m.sess, m.core, &QueryContext{
+			Type:    "DDL",
+			Builder: &Query{SQL: stmt.sql},
+			Model:   stmt.model,
+		})
+		if res.Err != nil {
+			return res.Err
+		}
+	}
+	return nil
+}
+
+// ddlStmt 记录语句对应的模型，执行的时候 middleware 需要用到
+type ddlStmt struct {
+	model *model.Model
+	sql   string
+}
+
+func (m *Migrator) diff(ctx context.Context) ([]ddlStmt, error) {
+	var res []ddlStmt
+	for _, entity := range m.entities {
+		mdl, err := m.r.Get(entity)
+		if err != nil {
+			return nil, err
+		}
+		columns, err := m.names(ctx, mdl, m.dialect.columnsSQL())
+		if err != nil {
+			return nil, err
+		}
+		// 没有任何列说明表不存在
+		if len(columns) == 0 {
+			stmts, err := m.CreateTable(entity)
+			if err != nil {
+				return nil, err
+			}
+			for _, stmt := range stmts {
+				res = append(res, ddlStmt{model: mdl, sql: stmt})
+			}
+			continue
+		}
+		b := m.newBuilder(mdl)
+		for _, fd := range mdl.Fields {
+			if _, ok := columns[fd.ColName]; ok {
+				continue
+			}
+			stmt, err := b.buildAddColumn(mdl, fd)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, ddlStmt{model: mdl, sql: stmt})
+		}
+		if len(mdl.Indexes) == 0 {
+			continue
+		}
+		indexes, err := m.names(ctx, mdl, m.dialect.indexesSQL())
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range mdl.Indexes {
+			if _, ok := indexes[idx.Name]; !ok {
+				res = append(res, ddlStmt{model: mdl, sql: b.buildCreateIndex(mdl, idx)})
+			}
+		}
+	}
+	return res, nil
+}
+
+// names 查询表的列名或者索引名，查询同样经过 middleware
+func (m *Migrator) names(ctx context.Context, mdl *model.Model, query string) (map[string]struct{}, error) {
+	c := m.core
+	c.model = mdl
+	res := iter(ctx, m.sess, c, &QueryContext{
+		Type:    "SELECT",
+		Builder: &Query{SQL: query, Args: []any{mdl.TableName}},
+		Model:   mdl,
+	})
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	rows, ok := res.Result.(*sql.Rows)
+	if !ok {
+		return nil, errs.NewErrUnsupportedResult(res.Result)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	names := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = struct{}{}
+	}
+	return names, rows.Err()
+}
+
+func (m *Migrator) newBuilder(mdl *model.Model) *builder {
+	c := m.core
+	c.model = mdl
+	return &builder{
+		core:   c,
+		quoter: c.dialect.quoter(),
+	}
+}
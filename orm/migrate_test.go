@@ -0,0 +1,108 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:migrate.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 表不存在
+	m := NewMigrator(db, &MigrateModelV1{})
+	stmts, err := m.Diff(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE `migrate_model` (`id` INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,`name` TEXT NOT NULL);",
+		"CREATE INDEX `idx_migrate_model_name` ON `migrate_model` (`name`);",
+	}, stmts)
+	require.NoError(t, m.Migrate(ctx))
+	stmts, err = m.Diff(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stmts)
+
+	// 新增列和索引
+	m = NewMigrator(db, &MigrateModelV2{})
+	stmts, err = m.Diff(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE `migrate_model` ADD COLUMN `email` TEXT NOT NULL DEFAULT '';",
+		"ALTER TABLE `migrate_model` ADD COLUMN `age` INTEGER;",
+		"CREATE UNIQUE INDEX `uk_migrate_model_email` ON `migrate_model` (`email`);",
+	}, stmts)
+	require.NoError(t, m.Migrate(ctx))
+	stmts, err = m.Diff(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stmts)
+
+	// 迁移之后可以正常读写
+	res := NewInserter[MigrateModelV2](db).Values(&MigrateModelV2{
+		Name: "Tom", Email: "tom@example.com"}).Exec(ctx)
+	require.NoError(t, res.Err())
+	got, err := NewSelector[MigrateModelV2](db).Where(C("Email").Eq("tom@example.com")).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &MigrateModelV2{Id: 1, Name: "Tom", Email: "tom@example.com"}, got)
+}
+
+func TestMigrator_MySQL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;").
+		WithArgs("migrate_model").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id").AddRow("name"))
+	mock.ExpectQuery("SELECT DISTINCT INDEX_NAME FROM information_schema.STATISTICS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;").
+		WithArgs("migrate_model").
+		WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME"}).AddRow("PRIMARY").AddRow("idx_migrate_model_name"))
+	mock.ExpectExec("ALTER TABLE `migrate_model` ADD COLUMN `email` VARCHAR(255) NOT NULL DEFAULT '';").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE `migrate_model` ADD COLUMN `age` INT;").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE UNIQUE INDEX `uk_migrate_model_email` ON `migrate_model` (`email`);").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var types []string
+	db.mdls = append(db.mdls, func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			types = append(types, qc.Type+" "+qc.Model.TableName)
+			return next(ctx, qc)
+		}
+	})
+	err = NewMigrator(db, &MigrateModelV2{}).Migrate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SELECT migrate_model", "SELECT migrate_model",
+		"DDL migrate_model", "DDL migrate_model", "DDL migrate_model"}, types)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type MigrateModelV1 struct {
+	Id   int64  `orm:"pk,auto_increment"`
+	Name string `orm:"index"`
+}
+
+func (MigrateModelV1) TableName() string {
+	return "migrate_model"
+}
+
+type MigrateModelV2 struct {
+	Id    int64  `orm:"pk,auto_increment"`
+	Name  string `orm:"index"`
+	Email string `orm:"unique,default=''"`
+	Age   *int32
+}
+
+func (MigrateModelV2) TableName() string {
+	return "migrate_model"
+}
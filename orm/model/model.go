@@ -2,6 +2,7 @@ package model
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"unicode"
@@ -10,7 +11,48 @@ import (
 )
 
 const (
-	tagKeyColumn = "column"
+	tagKeyColumn        = "column"
+	tagKeyPrimaryKey    = "pk"
+	tagKeyAutoIncrement = "auto_increment"
+	tagKeyDefault       = "default"
+	tagKeySize          = "size"
+	tagKeyNullable      = "nullable"
+	tagKeySoftDelete    = "soft_delete"
+	tagKeyVersion       = "version"
+	// 索引，可以指定名字，例如 orm:"index=idx_name"
+	// 多个字段使用同一个名字就是联合索引
+	tagKeyIndex  = "index"
+	tagKeyUnique = "unique"
+
+	// 关联关系，例如 orm:"rel=has_many,fk=user_id"
+	tagKeyRelation   = "rel"
+	tagKeyForeignKey = "fk"
+	tagKeyReferences = "ref"
+	tagKeyJoinTable  = "join"
+
+	// tagIgnore 代表这个字段不映射到任何列
+	tagIgnore = "-"
+)
+
+// tagFlags 是不需要值的标签，例如 orm:"column=id,pk,auto_increment"
+var tagFlags = map[string]struct{}{
+	tagKeyPrimaryKey:    {},
+	tagKeyAutoIncrement: {},
+	tagKeyNullable:      {},
+	tagKeySoftDelete:    {},
+	tagKeyVersion:       {},
+	tagKeyIndex:         {},
+	tagKeyUnique:        {},
+}
+
+// RelationKind 关联关系的类型
+type RelationKind string
+
+const (
+	HasOne     RelationKind = "has_one"
+	HasMany    RelationKind = "has_many"
+	BelongsTo  RelationKind = "belongs_to"
+	ManyToMany RelationKind = "many_to_many"
 )
 
 type Registry interface {
@@ -25,6 +67,23 @@ type Model struct {
 	FieldMap map[string]*Field
 	// 列名到字段定义的映射
 	ColumnMap map[string]*Field
+
+	// PrimaryKeys 主键，联合主键的时候会有多个
+	PrimaryKeys []*Field
+	// AutoIncrement 自增列，一张表最多只有一个
+	AutoIncrement *Field
+	// SoftDelete 软删除列，例如 DeletedAt，一张表最多只有一个
+	// 有软删除列的时候，DELETE 会变成 UPDATE，查询会过滤掉已经删除的数据
+	SoftDelete *Field
+	// Version 乐观锁的版本列，必须是整数，一张表最多只有一个
+	Version *Field
+	// Indexes 标签里面声明的索引，不包括主键
+	Indexes []*Index
+
+	// Relations 关联关系，关联字段不会出现在 Fields 里面
+	Relations []*Relation
+	// 字段名到关联关系的映射
+	RelationMap map[string]*Relation
 }
 
 type Option func(*Model) error
@@ -38,6 +97,59 @@ type Field struct {
 
 	// 字段相对于结构体本身的偏移量
 	Offset uintptr
+	// Index 字段的索引路径，和 reflect.Value.FieldByIndex 的参数一致
+	// 组合进来的字段会有多层
+	Index []int
+	// ViaPointer 代表字段在组合进来的指针里面，例如组合了 *BaseModel
+	// 这时候 Offset 是相对于 BaseModel 的，不能用起始地址加 Offset 来访问
+	ViaPointer bool
+
+	PrimaryKey    bool
+	AutoIncrement bool
+	// Default 列的默认值，原样用在 DDL 里面
+	Default string
+	// Size 例如 VARCHAR 的长度，0 代表没有指定
+	Size       int
+	Nullable   bool
+	SoftDelete bool
+	Version    bool
+	// IndexName 普通索引的名字，为空代表没有索引
+	IndexName string
+	// UniqueName 唯一索引的名字，为空代表没有唯一索引
+	UniqueName string
+
+	// defaultIndex 和 defaultUnique 代表标签里面没有指定索引的名字
+	// 要等表名确定之后才能生成默认的名字
+	defaultIndex  bool
+	defaultUnique bool
+}
+
+// Index 索引，Fields 的顺序就是字段声明的顺序
+type Index struct {
+	Name   string
+	Unique bool
+	Fields []*Field
+}
+
+// Relation 描述一个关联字段
+// HasOne 和 BelongsTo 的字段类型必须是 *T，HasMany 和 ManyToMany 必须是 []*T
+type Relation struct {
+	GoName string
+	Kind   RelationKind
+	// Elem 关联的结构体类型，例如 []*Order 里面的 Order
+	Elem  reflect.Type
+	Index []int
+
+	// ForeignKey 外键的列名
+	// 默认值里面的名字都是结构体名转下划线，例如 User 是 user
+	// HasOne 和 HasMany 是关联表里面的列，默认是 user_id
+	// BelongsTo 是当前表里面的列，默认是 字段名_id
+	// ManyToMany 是中间表里面指向当前表的列，默认是 user_id
+	ForeignKey string
+	// JoinTable 中间表，只用于 ManyToMany，默认是 user_role 这种形式
+	JoinTable string
+	// References 中间表里面指向关联表的列，只用于 ManyToMany，默认是 role_id
+	References string
 }
 
 // var models = map[reflect.Type]*Model{}
@@ -108,29 +220,38 @@ func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
 	// for elemTyp.Kind() == reflect.Pointer {
 	// 	elemTyp = elemTyp.Elem()
 	// }
-	numField := elemTyp.NumField()
-	fieldMap := make(map[string]*Field, numField)
-	columnMap := make(map[string]*Field, numField)
-	fields := make([]*Field, 0, numField)
-	for i := 0; i < numField; i++ {
-		fd := elemTyp.Field(i)
-		pair, err := r.parseTag(fd.Tag)
-		if err != nil {
-			return nil, err
+	fields, rels, err := r.parseFields(elemTyp, nil, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	fieldMap := make(map[string]*Field, len(fields))
+	columnMap := make(map[string]*Field, len(fields))
+	var pks []*Field
+	var autoIncrement, softDelete, version *Field
+	for _, fdMeta := range fields {
+		if fdMeta.PrimaryKey {
+			pks = append(pks, fdMeta)
 		}
-		colName := pair[tagKeyColumn]
-		if colName == "" {
-			colName = underscoreName(fd.Name)
+		if fdMeta.AutoIncrement {
+			if autoIncrement != nil {
+				return nil, errs.NewErrMultipleAutoIncrement(autoIncrement.GoName, fdMeta.GoName)
+			}
+			autoIncrement = fdMeta
 		}
-		fdMeta := &Field{
-			GoName:  fd.Name,
-			ColName: colName,
-			Type:    fd.Type,
-			Offset:  fd.Offset,
+		if fdMeta.SoftDelete {
+			if softDelete != nil {
+				return nil, errs.NewErrMultipleSoftDelete(softDelete.GoName, fdMeta.GoName)
+			}
+			softDelete = fdMeta
 		}
-		fieldMap[fd.Name] = fdMeta
-		columnMap[colName] = fdMeta
-		fields = append(fields, fdMeta)
+		if fdMeta.Version {
+			if version != nil {
+				return nil, errs.NewErrMultipleVersion(version.GoName, fdMeta.GoName)
+			}
+			version = fdMeta
+		}
+		fieldMap[fdMeta.GoName] = fdMeta
+		columnMap[fdMeta.ColName] = fdMeta
 	}
 
 	var tableName string
@@ -141,11 +262,35 @@ func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
 		tableName = underscoreName(elemTyp.Name())
 	}
 
+	var relMap map[string]*Relation
+	if len(rels) > 0 {
+		relMap = make(map[string]*Relation, len(rels))
+	}
+	for _, rel := range rels {
+		if _, ok := relMap[rel.GoName]; ok {
+			return nil, errs.NewErrDuplicateField(rel.GoName)
+		}
+		rel.setDefaults(elemTyp)
+		// BelongsTo 的外键在当前表，可以提前校验
+		if rel.Kind == BelongsTo {
+			if _, ok := columnMap[rel.ForeignKey]; !ok {
+				return nil, errs.NewErrUnknownColumn(rel.ForeignKey)
+			}
+		}
+		relMap[rel.GoName] = rel
+	}
+
 	res := &Model{
-		TableName: tableName,
-		FieldMap:  fieldMap,
-		ColumnMap: columnMap,
-		Fields:    fields,
+		TableName:     tableName,
+		FieldMap:      fieldMap,
+		ColumnMap:     columnMap,
+		Fields:        fields,
+		PrimaryKeys:   pks,
+		AutoIncrement: autoIncrement,
+		SoftDelete:    softDelete,
+		Version:       version,
+		Relations:     rels,
+		RelationMap:   relMap,
 	}
 
 	for _, opt := range opts {
@@ -155,6 +300,13 @@ func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
 		}
 	}
 
+	// WithTableName 和 WithColumnName 可能修改了名字，所以最后处理索引
+	setIndexNames(res.TableName, res.Fields)
+	res.Indexes, err = buildIndexes(res.Fields)
+	if err != nil {
+		return nil, err
+	}
+
 	r.models.Store(typ, res)
 	return res, nil
 }
@@ -189,6 +341,10 @@ func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	pairs := strings.Split(ormTag, ",")
 	res := make(map[string]string, len(pairs))
 	for _, pair := range pairs {
+		if _, ok := tagFlags[pair]; ok {
+			res[pair] = ""
+			continue
+		}
 		segs := strings.Split(pair, "=")
 		if len(segs) != 2 {
 			return nil, errs.NewErrInvalidTagContent(pair)
@@ -200,6 +356,229 @@ func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	return res, nil
 }
 
+// parseFields 解析结构体的字段
+// 组合的结构体会被展开，例如组合了 BaseModel，那么 BaseModel 的字段就是当前模型的字段
+// index 和 offset 是外层结构体的索引路径和偏移量
+// viaPtr 代表外层有一层是组合的指针
+func (r *registry) parseFields(typ reflect.Type, index []int,
+	offset uintptr, viaPtr bool) ([]*Field, []*Relation, error) {
+	numField := typ.NumField()
+	fields := make([]*Field, 0, numField)
+	var rels []*Relation
+	// 和 Go 的规则保持一致，同名的字段，层级浅的覆盖层级深的
+	positions := make(map[string]int, numField)
+	add := func(fdMeta *Field) error {
+		pos, ok := positions[fdMeta.GoName]
+		if !ok {
+			positions[fdMeta.GoName] = len(fields)
+			fields = append(fields, fdMeta)
+			return nil
+		}
+		old := fields[pos]
+		switch {
+		case len(old.Index) == len(fdMeta.Index):
+			return errs.NewErrDuplicateField(fdMeta.GoName)
+		case len(old.Index) > len(fdMeta.Index):
+			fields[pos] = fdMeta
+		}
+		return nil
+	}
+
+	for i := 0; i < numField; i++ {
+		fd := typ.Field(i)
+		if fd.Tag.Get("orm") == tagIgnore {
+			continue
+		}
+		fdIndex := make([]int, len(index)+1)
+		copy(fdIndex, index)
+		fdIndex[len(index)] = i
+
+		if fd.Anonymous {
+			embedded, embeddedViaPtr, embeddedOffset := fd.Type, viaPtr, offset+fd.Offset
+			if embedded.Kind() == reflect.Pointer {
+				// 指针指向的结构体在别的地方，偏移量要重新算
+				embedded, embeddedViaPtr, embeddedOffset = embedded.Elem(), true, 0
+			}
+			if embedded.Kind() == reflect.Struct {
+				subFields, subRels, err := r.parseFields(embedded, fdIndex, embeddedOffset, embeddedViaPtr)
+				if err != nil {
+					return nil, nil, err
+				}
+				rels = append(rels, subRels...)
+				for _, sub := range subFields {
+					if err = add(sub); err != nil {
+						return nil, nil, err
+					}
+				}
+				continue
+			}
+		}
+
+		pair, err := r.parseTag(fd.Tag)
+		if err != nil {
+			return nil, nil, err
+		}
+		if kind, ok := pair[tagKeyRelation]; ok {
+			rel, err := newRelation(fd, fdIndex, RelationKind(kind), pair)
+			if err != nil {
+				return nil, nil, err
+			}
+			rels = append(rels, rel)
+			continue
+		}
+		colName := pair[tagKeyColumn]
+		if colName == "" {
+			colName = underscoreName(fd.Name)
+		}
+		fdMeta := &Field{
+			GoName:     fd.Name,
+			ColName:    colName,
+			Type:       fd.Type,
+			Offset:     offset + fd.Offset,
+			Index:      fdIndex,
+			ViaPointer: viaPtr,
+		}
+		if err = fdMeta.setTagOptions(pair); err != nil {
+			return nil, nil, err
+		}
+		if err = add(fdMeta); err != nil {
+			return nil, nil, err
+		}
+	}
+	return fields, rels, nil
+}
+
+// setTagOptions 处理 column 之外的标签
+func (f *Field) setTagOptions(pair map[string]string) error {
+	_, f.PrimaryKey = pair[tagKeyPrimaryKey]
+	_, f.AutoIncrement = pair[tagKeyAutoIncrement]
+	_, f.Nullable = pair[tagKeyNullable]
+	_, f.SoftDelete = pair[tagKeySoftDelete]
+	_, f.Version = pair[tagKeyVersion]
+	if f.Version {
+		switch f.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return errs.NewErrInvalidVersionType(f.GoName, f.Type)
+		}
+	}
+	// 没有指定名字的时候，在 setIndexNames 里面生成
+	if name, ok := pair[tagKeyIndex]; ok {
+		f.IndexName = name
+		f.defaultIndex = name == ""
+	}
+	if name, ok := pair[tagKeyUnique]; ok {
+		f.UniqueName = name
+		f.defaultUnique = name == ""
+	}
+	f.Default = pair[tagKeyDefault]
+	if size, ok := pair[tagKeySize]; ok {
+		val, err := strconv.Atoi(size)
+		if err != nil || val <= 0 {
+			return errs.NewErrInvalidTagContent(tagKeySize + "=" + size)
+		}
+		f.Size = val
+	}
+	return nil
+}
+
+// setIndexNames 没有指定名字的索引用 idx_表名_列名 和 uk_表名_列名
+// PostgreSQL 和 SQLite 的索引名在整个 schema 里面唯一，所以要带上表名
+func setIndexNames(tableName string, fields []*Field) {
+	for _, fd := range fields {
+		if fd.defaultIndex {
+			fd.IndexName = "idx_" + tableName + "_" + fd.ColName
+		}
+		if fd.defaultUnique {
+			fd.UniqueName = "uk_" + tableName + "_" + fd.ColName
+		}
+	}
+}
+
+// buildIndexes 把同名的索引合并成联合索引
+func buildIndexes(fields []*Field) ([]*Index, error) {
+	var indexes []*Index
+	idxMap := make(map[string]*Index)
+	add := func(name string, unique bool, fd *Field) error {
+		idx, ok := idxMap[name]
+		if !ok {
+			idx = &Index{Name: name, Unique: unique}
+			idxMap[name] = idx
+			indexes = append(indexes, idx)
+		}
+		// 同一个名字不能既是普通索引又是唯一索引
+		if idx.Unique != unique {
+			return errs.NewErrInvalidTagContent(name)
+		}
+		idx.Fields = append(idx.Fields, fd)
+		return nil
+	}
+	for _, fd := range fields {
+		if fd.IndexName != "" {
+			if err := add(fd.IndexName, false, fd); err != nil {
+				return nil, err
+			}
+		}
+		if fd.UniqueName != "" {
+			if err := add(fd.UniqueName, true, fd); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return indexes, nil
+}
+
+func newRelation(fd reflect.StructField, index []int,
+	kind RelationKind, pair map[string]string) (*Relation, error) {
+	typ := fd.Type
+	switch kind {
+	case HasOne, BelongsTo:
+	case HasMany, ManyToMany:
+		if typ.Kind() != reflect.Slice {
+			return nil, errs.NewErrInvalidRelationType(fd.Name, fd.Type)
+		}
+		typ = typ.Elem()
+	default:
+		return nil, errs.NewErrInvalidTagContent(tagKeyRelation + "=" + string(kind))
+	}
+	if typ.Kind() != reflect.Pointer || typ.Elem().Kind() != reflect.Struct {
+		return nil, errs.NewErrInvalidRelationType(fd.Name, fd.Type)
+	}
+	return &Relation{
+		GoName:     fd.Name,
+		Kind:       kind,
+		Elem:       typ.Elem(),
+		Index:      index,
+		ForeignKey: pair[tagKeyForeignKey],
+		JoinTable:  pair[tagKeyJoinTable],
+		References: pair[tagKeyReferences],
+	}, nil
+}
+
+// setDefaults 补全没有在标签里面指定的列名
+// owner 是声明关联字段的模型，组合的时候是最外层的结构体
+func (rel *Relation) setDefaults(owner reflect.Type) {
+	ownerName := underscoreName(owner.Name())
+	elemName := underscoreName(rel.Elem.Name())
+	if rel.ForeignKey == "" {
+		if rel.Kind == BelongsTo {
+			rel.ForeignKey = underscoreName(rel.GoName) + "_id"
+		} else {
+			rel.ForeignKey = ownerName + "_id"
+		}
+	}
+	if rel.Kind != ManyToMany {
+		return
+	}
+	if rel.JoinTable == "" {
+		rel.JoinTable = ownerName + "_" + elemName
+	}
+	if rel.References == "" {
+		rel.References = elemName + "_id"
+	}
+}
+
 // underscoreName 驼峰转字符串命名
 func underscoreName(tableName string) string {
 	var buf []byte
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
 	"github.com/stretchr/testify/assert"
@@ -33,23 +34,27 @@ func TestRegistry_Register(t *testing.T) {
 						ColName: "id",
 						GoName:  "Id",
 						Type:    reflect.TypeOf(int64(0)),
+						Index:   []int{0},
 					},
 					{
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{1},
 						Offset:  8,
 					},
 					{
 						ColName: "age",
 						GoName:  "Age",
 						Type:    reflect.TypeOf(int8(0)),
+						Index:   []int{2},
 						Offset:  24,
 					},
 					{
 						ColName: "last_name",
 						GoName:  "LastName",
 						Type:    reflect.TypeOf(&sql.NullString{}),
+						Index:   []int{3},
 						Offset:  32,
 					},
 				},
@@ -113,23 +118,27 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "id",
 						GoName:  "Id",
 						Type:    reflect.TypeOf(int64(0)),
+						Index:   []int{0},
 					},
 					{
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{1},
 						Offset:  8,
 					},
 					{
 						ColName: "age",
 						GoName:  "Age",
 						Type:    reflect.TypeOf(int8(0)),
+						Index:   []int{2},
 						Offset:  24,
 					},
 					{
 						ColName: "last_name",
 						GoName:  "LastName",
 						Type:    reflect.TypeOf(&sql.NullString{}),
+						Index:   []int{3},
 						Offset:  32,
 					},
 				},
@@ -152,6 +161,7 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "first_name_t",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
 					},
 				},
 			},
@@ -171,6 +181,7 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
 					},
 				},
 			},
@@ -200,10 +211,358 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
 					},
 				},
 			},
 		},
+		{
+			name: "key and options",
+			entity: func() any {
+				type KeyTable struct {
+					Id        int64  `orm:"pk,auto_increment"`
+					FirstName string `orm:"column=name,size=64,default='Tom'"`
+					LastName  string `orm:"nullable"`
+					Ignored   string `orm:"-"`
+				}
+				return &KeyTable{}
+			}(),
+			wantModel: func() *Model {
+				id := &Field{
+					ColName:       "id",
+					GoName:        "Id",
+					Type:          reflect.TypeOf(int64(0)),
+					Index:         []int{0},
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				}
+				return &Model{
+					TableName: "key_table",
+					Fields: []*Field{
+						id,
+						{
+							ColName: "name",
+							GoName:  "FirstName",
+							Type:    reflect.TypeOf(""),
+							Index:   []int{1},
+							Offset:  8,
+							Size:    64,
+							Default: "'Tom'",
+						},
+						{
+							ColName:  "last_name",
+							GoName:   "LastName",
+							Type:     reflect.TypeOf(""),
+							Index:    []int{2},
+							Offset:   24,
+							Nullable: true,
+						},
+					},
+					PrimaryKeys:   []*Field{id},
+					AutoIncrement: id,
+				}
+			}(),
+		},
+		{
+			name: "composite primary key",
+			entity: func() any {
+				type CompositeKey struct {
+					UserId  int64 `orm:"pk"`
+					OrderId int64 `orm:"pk"`
+				}
+				return &CompositeKey{}
+			}(),
+			wantModel: func() *Model {
+				userId := &Field{
+					ColName:    "user_id",
+					GoName:     "UserId",
+					Type:       reflect.TypeOf(int64(0)),
+					Index:      []int{0},
+					PrimaryKey: true,
+				}
+				orderId := &Field{
+					ColName:    "order_id",
+					GoName:     "OrderId",
+					Type:       reflect.TypeOf(int64(0)),
+					Index:      []int{1},
+					Offset:     8,
+					PrimaryKey: true,
+				}
+				return &Model{
+					TableName:   "composite_key",
+					Fields:      []*Field{userId, orderId},
+					PrimaryKeys: []*Field{userId, orderId},
+				}
+			}(),
+		},
+		{
+			name: "invalid size",
+			entity: func() any {
+				type InvalidSize struct {
+					FirstName string `orm:"size=abc"`
+				}
+				return &InvalidSize{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("size=abc"),
+		},
+		{
+			name: "multiple auto increment",
+			entity: func() any {
+				type MultipleAutoIncrement struct {
+					Id  int64 `orm:"auto_increment"`
+					Seq int64 `orm:"auto_increment"`
+				}
+				return &MultipleAutoIncrement{}
+			}(),
+			wantErr: errs.NewErrMultipleAutoIncrement("Id", "Seq"),
+		},
+		{
+			name: "soft delete",
+			entity: func() any {
+				type SoftDeleteTable struct {
+					Id        int64
+					DeletedAt *time.Time `orm:"soft_delete"`
+				}
+				return &SoftDeleteTable{}
+			}(),
+			wantModel: func() *Model {
+				deletedAt := &Field{
+					ColName:    "deleted_at",
+					GoName:     "DeletedAt",
+					Type:       reflect.TypeOf(&time.Time{}),
+					Index:      []int{1},
+					Offset:     8,
+					SoftDelete: true,
+				}
+				return &Model{
+					TableName: "soft_delete_table",
+					Fields: []*Field{
+						{
+							ColName: "id",
+							GoName:  "Id",
+							Type:    reflect.TypeOf(int64(0)),
+							Index:   []int{0},
+						},
+						deletedAt,
+					},
+					SoftDelete: deletedAt,
+				}
+			}(),
+		},
+		{
+			name: "multiple soft delete",
+			entity: func() any {
+				type MultipleSoftDelete struct {
+					DeletedAt *time.Time `orm:"soft_delete"`
+					RemovedAt *time.Time `orm:"soft_delete"`
+				}
+				return &MultipleSoftDelete{}
+			}(),
+			wantErr: errs.NewErrMultipleSoftDelete("DeletedAt", "RemovedAt"),
+		},
+		{
+			name: "version",
+			entity: func() any {
+				type VersionTable struct {
+					Version int64 `orm:"version"`
+				}
+				return &VersionTable{}
+			}(),
+			wantModel: func() *Model {
+				version := &Field{
+					ColName: "version",
+					GoName:  "Version",
+					Type:    reflect.TypeOf(int64(0)),
+					Index:   []int{0},
+					Version: true,
+				}
+				return &Model{
+					TableName: "version_table",
+					Fields:    []*Field{version},
+					Version:   version,
+				}
+			}(),
+		},
+		{
+			name: "multiple version",
+			entity: func() any {
+				type MultipleVersion struct {
+					Version  int64 `orm:"version"`
+					Revision int64 `orm:"version"`
+				}
+				return &MultipleVersion{}
+			}(),
+			wantErr: errs.NewErrMultipleVersion("Version", "Revision"),
+		},
+		{
+			name: "invalid version type",
+			entity: func() any {
+				type InvalidVersion struct {
+					Version string `orm:"version"`
+				}
+				return &InvalidVersion{}
+			}(),
+			wantErr: errs.NewErrInvalidVersionType("Version", reflect.TypeOf("")),
+		},
+		{
+			name: "indexes",
+			entity: func() any {
+				type IndexTable struct {
+					Email    string `orm:"unique"`
+					TenantId int64  `orm:"index=idx_tenant_name"`
+					Name     string `orm:"index=idx_tenant_name"`
+				}
+				return &IndexTable{}
+			}(),
+			wantModel: func() *Model {
+				email := &Field{
+					ColName:    "email",
+					GoName:     "Email",
+					Type:       reflect.TypeOf(""),
+					Index:      []int{0},
+					UniqueName: "uk_index_table_email",
+
+					defaultUnique: true,
+				}
+				tenantId := &Field{
+					ColName:   "tenant_id",
+					GoName:    "TenantId",
+					Type:      reflect.TypeOf(int64(0)),
+					Index:     []int{1},
+					Offset:    16,
+					IndexName: "idx_tenant_name",
+				}
+				name := &Field{
+					ColName:   "name",
+					GoName:    "Name",
+					Type:      reflect.TypeOf(""),
+					Index:     []int{2},
+					Offset:    24,
+					IndexName: "idx_tenant_name",
+				}
+				return &Model{
+					TableName: "index_table",
+					Fields:    []*Field{email, tenantId, name},
+					Indexes: []*Index{
+						{Name: "uk_index_table_email", Unique: true, Fields: []*Field{email}},
+						{Name: "idx_tenant_name", Fields: []*Field{tenantId, name}},
+					},
+				}
+			}(),
+		},
+		{
+			name: "index name conflict",
+			entity: func() any {
+				type ConflictIndex struct {
+					Email string `orm:"unique=idx_email"`
+					Name  string `orm:"index=idx_email"`
+				}
+				return &ConflictIndex{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("idx_email"),
+		},
+		{
+			name:   "embedded",
+			entity: &EmbeddedModel{},
+			wantModel: &Model{
+				TableName: "embedded_model",
+				Fields: []*Field{
+					{
+						ColName: "id",
+						GoName:  "Id",
+						Type:    reflect.TypeOf(int64(0)),
+						Index:   []int{0, 0},
+					},
+					{
+						ColName: "create_time",
+						GoName:  "CreateTime",
+						Type:    reflect.TypeOf(int64(0)),
+						Offset:  8,
+						Index:   []int{0, 1},
+					},
+					{
+						ColName: "first_name",
+						GoName:  "FirstName",
+						Type:    reflect.TypeOf(""),
+						Offset:  16,
+						Index:   []int{1},
+					},
+				},
+			},
+		},
+		{
+			name:   "embedded pointer",
+			entity: &EmbeddedPtrModel{},
+			wantModel: &Model{
+				TableName: "embedded_ptr_model",
+				Fields: []*Field{
+					{
+						ColName: "first_name",
+						GoName:  "FirstName",
+						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
+					},
+					{
+						ColName:    "id",
+						GoName:     "Id",
+						Type:       reflect.TypeOf(int64(0)),
+						Index:      []int{1, 0},
+						ViaPointer: true,
+					},
+					{
+						ColName:    "create_time",
+						GoName:     "CreateTime",
+						Type:       reflect.TypeOf(int64(0)),
+						Offset:     8,
+						Index:      []int{1, 1},
+						ViaPointer: true,
+					},
+				},
+			},
+		},
+		{
+			name: "shadowed field",
+			entity: func() any {
+				type ShadowModel struct {
+					BaseModel
+					Id string `orm:"column=uid"`
+				}
+				return &ShadowModel{}
+			}(),
+			wantModel: &Model{
+				TableName: "shadow_model",
+				Fields: []*Field{
+					{
+						ColName: "uid",
+						GoName:  "Id",
+						Type:    reflect.TypeOf(""),
+						Offset:  16,
+						Index:   []int{1},
+					},
+					{
+						ColName: "create_time",
+						GoName:  "CreateTime",
+						Type:    reflect.TypeOf(int64(0)),
+						Offset:  8,
+						Index:   []int{0, 1},
+					},
+				},
+			},
+		},
+		{
+			name: "duplicate field",
+			entity: func() any {
+				type OtherBase struct {
+					Id int64
+				}
+				type DuplicateModel struct {
+					BaseModel
+					OtherBase
+				}
+				return &DuplicateModel{}
+			}(),
+			wantErr: errs.NewErrDuplicateField("Id"),
+		},
 		{
 			name:   "table name",
 			entity: &CustomTableName{},
@@ -214,6 +573,7 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
 					},
 				},
 			},
@@ -228,6 +588,7 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
 					},
 				},
 			},
@@ -242,6 +603,7 @@ func TestRegistry_get(t *testing.T) {
 						ColName: "first_name",
 						GoName:  "FirstName",
 						Type:    reflect.TypeOf(""),
+						Index:   []int{0},
 					},
 				},
 			},
@@ -276,6 +638,21 @@ func TestRegistry_get(t *testing.T) {
 	}
 }
 
+type BaseModel struct {
+	Id         int64
+	CreateTime int64
+}
+
+type EmbeddedModel struct {
+	BaseModel
+	FirstName string
+}
+
+type EmbeddedPtrModel struct {
+	FirstName string
+	*BaseModel
+}
+
 type CustomTableName struct {
 	FirstName string
 }
@@ -307,6 +684,20 @@ func TestModelWithTableName(t *testing.T) {
 	assert.Equal(t, "test_model_ttt", m.TableName)
 }
 
+func TestModelDefaultIndexName(t *testing.T) {
+	type IndexModel struct {
+		UserId int64  `orm:"index"`
+		Email  string `orm:"unique"`
+	}
+	r := NewRegistry()
+	// 默认的索引名要用最终的表名和列名
+	m, err := r.Register(&IndexModel{}, WithTableName("user_t"), WithColumneName("Email", "mail"))
+	require.NoError(t, err)
+	require.Len(t, m.Indexes, 2)
+	assert.Equal(t, "idx_user_t_user_id", m.Indexes[0].Name)
+	assert.Equal(t, "uk_user_t_mail", m.Indexes[1].Name)
+}
+
 func TestModelWithColumnName(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -352,3 +743,142 @@ type TestModel struct {
 	Age       int8
 	LastName  *sql.NullString
 }
+
+func TestRegistry_Relations(t *testing.T) {
+	type Order struct {
+		Id     int64
+		UserId int64
+	}
+	type Role struct {
+		Id int64
+	}
+	type User struct {
+		Id      int64
+		Orders  []*Order `orm:"rel=has_many"`
+		Profile *Order   `orm:"rel=has_one,fk=owner_id"`
+		Roles   []*Role  `orm:"rel=many_to_many"`
+		Admins  []*Role  `orm:"rel=many_to_many,join=admin,fk=uid,ref=rid"`
+	}
+	type Item struct {
+		Id     int64
+		UserId int64
+		User   *User `orm:"rel=belongs_to"`
+	}
+	testCases := []struct {
+		name   string
+		entity any
+
+		wantRelations []*Relation
+		wantErr       error
+	}{
+		{
+			name:   "has and many to many",
+			entity: &User{},
+			wantRelations: []*Relation{
+				{
+					GoName:     "Orders",
+					Kind:       HasMany,
+					Elem:       reflect.TypeOf(Order{}),
+					Index:      []int{1},
+					ForeignKey: "user_id",
+				},
+				{
+					GoName:     "Profile",
+					Kind:       HasOne,
+					Elem:       reflect.TypeOf(Order{}),
+					Index:      []int{2},
+					ForeignKey: "owner_id",
+				},
+				{
+					GoName:     "Roles",
+					Kind:       ManyToMany,
+					Elem:       reflect.TypeOf(Role{}),
+					Index:      []int{3},
+					ForeignKey: "user_id",
+					JoinTable:  "user_role",
+					References: "role_id",
+				},
+				{
+					GoName:     "Admins",
+					Kind:       ManyToMany,
+					Elem:       reflect.TypeOf(Role{}),
+					Index:      []int{4},
+					ForeignKey: "uid",
+					JoinTable:  "admin",
+					References: "rid",
+				},
+			},
+		},
+		{
+			name:   "belongs to",
+			entity: &Item{},
+			wantRelations: []*Relation{
+				{
+					GoName:     "User",
+					Kind:       BelongsTo,
+					Elem:       reflect.TypeOf(User{}),
+					Index:      []int{2},
+					ForeignKey: "user_id",
+				},
+			},
+		},
+		{
+			name: "belongs to unknown column",
+			entity: func() any {
+				type Item struct {
+					Id   int64
+					User *User `orm:"rel=belongs_to"`
+				}
+				return &Item{}
+			}(),
+			wantErr: errs.NewErrUnknownColumn("user_id"),
+		},
+		{
+			name: "invalid kind",
+			entity: func() any {
+				type Item struct {
+					User *User `orm:"rel=has_some"`
+				}
+				return &Item{}
+			}(),
+			wantErr: errs.NewErrInvalidTagContent("rel=has_some"),
+		},
+		{
+			name: "has many not slice",
+			entity: func() any {
+				type Item struct {
+					Users *User `orm:"rel=has_many"`
+				}
+				return &Item{}
+			}(),
+			wantErr: errs.NewErrInvalidRelationType("Users", reflect.TypeOf(&User{})),
+		},
+		{
+			name: "has one not pointer",
+			entity: func() any {
+				type Item struct {
+					User User `orm:"rel=has_one"`
+				}
+				return &Item{}
+			}(),
+			wantErr: errs.NewErrInvalidRelationType("User", reflect.TypeOf(User{})),
+		},
+	}
+
+	r := &registry{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := r.Register(tc.entity)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantRelations, m.Relations)
+			assert.Equal(t, len(tc.wantRelations), len(m.RelationMap))
+			for _, rel := range tc.wantRelations {
+				_, ok := m.FieldMap[rel.GoName]
+				assert.False(t, ok)
+			}
+		})
+	}
+}
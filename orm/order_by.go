@@ -0,0 +1,23 @@
+package orm
+
+// OrderBy 代表 ORDER BY 里面的一个排序项
+type OrderBy struct {
+	col   string
+	order string
+}
+
+// Asc 升序，例如 Asc("Age")
+func Asc(col string) OrderBy {
+	return OrderBy{
+		col:   col,
+		order: "ASC",
+	}
+}
+
+// Desc 降序，例如 Desc("Age")
+func Desc(col string) OrderBy {
+	return OrderBy{
+		col:   col,
+		order: "DESC",
+	}
+}
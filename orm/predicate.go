@@ -3,11 +3,27 @@ package orm
 type op string
 
 const (
-	opEq  op = "="
-	opLT  op = "<"
-	opNot op = "NOT"
-	opAnd op = "AND"
-	opOr  op = "OR"
+	opEq        op = "="
+	opNEQ       op = "!="
+	opLT        op = "<"
+	opLE        op = "<="
+	opGT        op = ">"
+	opGE        op = ">="
+	opIn        op = "IN"
+	opNotIn     op = "NOT IN"
+	opLike      op = "LIKE"
+	opNotLike   op = "NOT LIKE"
+	opBetween   op = "BETWEEN"
+	opIsNull    op = "IS NULL"
+	opIsNotNull op = "IS NOT NULL"
+	opNot       op = "NOT"
+	opAnd       op = "AND"
+	opOr        op = "OR"
+
+	opAdd      op = "+"
+	opSub      op = "-"
+	opMultiply op = "*"
+	opDivide   op = "/"
 )
 
 func (o op) String() string {
@@ -64,5 +80,16 @@ type value struct {
 	val any
 }
 
-func (value) expr()     {}
-func (Predicate) expr() {}
+// values 代表 IN 后面的值列表，构造成 (?,?,?)
+type values []any
+
+// betweenValues 代表 BETWEEN 后面的两个值，构造成 ? AND ?
+type betweenValues struct {
+	low  any
+	high any
+}
+
+func (value) expr()         {}
+func (values) expr()        {}
+func (betweenValues) expr() {}
+func (Predicate) expr()     {}
@@ -0,0 +1,373 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/jackycsl/geektime-go-practical/orm/model"
+)
+
+// preloadQuery 构造关联查询
+// SELECT * FROM `order` WHERE `user_id` IN (?,?);
+type preloadQuery struct {
+	builder
+	table string
+	// columns 为空代表查询所有列
+	columns []string
+	col     string
+	vals    []any
+	// softDelete 软删除列，不为空的时候过滤掉已经删除的数据
+	softDelete string
+}
+
+func (q *preloadQuery) Build() (*Query, error) {
+	q.reset()
+	q.sb.WriteString("SELECT ")
+	if len(q.columns) == 0 {
+		q.sb.WriteByte('*')
+	}
+	for i, c := range q.columns {
+		if i > 0 {
+			q.sb.WriteByte(',')
+		}
+		q.quote(c)
+	}
+	q.sb.WriteString(" FROM ")
+	q.quote(q.table)
+	q.sb.WriteString(" WHERE ")
+	q.quote(q.col)
+	q.sb.WriteString(" IN ")
+	if err := q.buildExpression(values(q.vals)); err != nil {
+		return nil, err
+	}
+	if q.softDelete != "" {
+		q.sb.WriteString(" AND ")
+		q.quote(q.softDelete)
+		q.sb.WriteString(" IS NULL")
+	}
+	q.sb.WriteByte(';')
+	return &Query{
+		SQL:  q.sb.String(),
+		Args: q.args,
+	}, nil
+}
+
+// preloader 负责加载一个关联关系
+// 每个关联关系只会额外发起一次 IN 查询，ManyToMany 要多查一次中间表
+type preloader struct {
+	c        core
+	sess     Session
+	unscoped bool
+}
+
+func (p preloader) load(ctx context.Context, rel *model.Relation, owners []any) error {
+	relModel, err := p.c.r.Get(reflect.New(rel.Elem).Interface())
+	if err != nil {
+		return err
+	}
+	switch rel.Kind {
+	case model.HasOne, model.HasMany:
+		return p.loadHas(ctx, rel, relModel, owners)
+	case model.BelongsTo:
+		return p.loadBelongsTo(ctx, rel, relModel, owners)
+	case model.ManyToMany:
+		return p.loadManyToMany(ctx, rel, relModel, owners)
+	default:
+		return errs.NewErrUnknownRelation(rel.GoName)
+	}
+}
+
+// loadHas 外键在关联表里面，指向当前表的主键
+func (p preloader) loadHas(ctx context.Context, rel *model.Relation,
+	relModel *model.Model, owners []any) error {
+	pk, err := primaryKey(p.c.model)
+	if err != nil {
+		return err
+	}
+	fk, ok := relModel.ColumnMap[rel.ForeignKey]
+	if !ok {
+		return errs.NewErrUnknownColumn(rel.ForeignKey)
+	}
+	keys, args, err := p.keysOf(p.c.model, owners, pk)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+	related, err := p.query(ctx, rel, relModel, fk.ColName, args)
+	if err != nil {
+		return err
+	}
+	relKeys, _, err := p.keysOf(relModel, related, fk)
+	if err != nil {
+		return err
+	}
+	groups := make(map[any][]any, len(args))
+	for i, k := range relKeys {
+		groups[k] = append(groups[k], related[i])
+	}
+	for i, owner := range owners {
+		if err = setRelation(owner, rel, groups[keys[i]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBelongsTo 外键在当前表里面，指向关联表的主键
+func (p preloader) loadBelongsTo(ctx context.Context, rel *model.Relation,
+	relModel *model.Model, owners []any) error {
+	pk, err := primaryKey(relModel)
+	if err != nil {
+		return err
+	}
+	fk := p.c.model.ColumnMap[rel.ForeignKey]
+	keys, args, err := p.keysOf(p.c.model, owners, fk)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+	related, err := p.query(ctx, rel, relModel, pk.ColName, args)
+	if err != nil {
+		return err
+	}
+	relKeys, _, err := p.keysOf(relModel, related, pk)
+	if err != nil {
+		return err
+	}
+	groups := make(map[any][]any, len(related))
+	for i, k := range relKeys {
+		groups[k] = append(groups[k], related[i])
+	}
+	for i, owner := range owners {
+		if err = setRelation(owner, rel, groups[keys[i]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadManyToMany 先查中间表，再用关联表的主键查关联表
+func (p preloader) loadManyToMany(ctx context.Context, rel *model.Relation,
+	relModel *model.Model, owners []any) error {
+	pk, err := primaryKey(p.c.model)
+	if err != nil {
+		return err
+	}
+	relPK, err := primaryKey(relModel)
+	if err != nil {
+		return err
+	}
+	keys, args, err := p.keysOf(p.c.model, owners, pk)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+
+	// 中间表没有模型，用主键的类型来接收数据
+	joinModel := &model.Model{TableName: rel.JoinTable}
+	rows, _, err := p.rows(ctx, joinModel, &preloadQuery{
+		table:   rel.JoinTable,
+		columns: []string{rel.ForeignKey, rel.References},
+		col:     rel.ForeignKey,
+		vals:    args,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	type pair struct {
+		owner any
+		ref   any
+	}
+	var pairs []pair
+	var refArgs []any
+	refSet := make(map[any]struct{})
+	for rows.Next() {
+		ownerVal, refVal := reflect.New(pk.Type), reflect.New(relPK.Type)
+		if err = rows.Scan(ownerVal.Interface(), refVal.Interface()); err != nil {
+			return err
+		}
+		ownerKey, err := relationKey(ownerVal.Elem().Interface())
+		if err != nil {
+			return err
+		}
+		refKey, err := relationKey(refVal.Elem().Interface())
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair{owner: ownerKey, ref: refKey})
+		if _, ok := refSet[refKey]; !ok {
+			refSet[refKey] = struct{}{}
+			refArgs = append(refArgs, refVal.Elem().Interface())
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	groups := make(map[any][]any, len(args))
+	if len(refArgs) > 0 {
+		related, err := p.query(ctx, rel, relModel, relPK.ColName, refArgs)
+		if err != nil {
+			return err
+		}
+		relKeys, _, err := p.keysOf(relModel, related, relPK)
+		if err != nil {
+			return err
+		}
+		relMap := make(map[any]any, len(related))
+		for i, k := range relKeys {
+			relMap[k] = related[i]
+		}
+		for _, pr := range pairs {
+			if r, ok := relMap[pr.ref]; ok {
+				groups[pr.owner] = append(groups[pr.owner], r)
+			}
+		}
+	}
+	for i, owner := range owners {
+		if err = setRelation(owner, rel, groups[keys[i]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keysOf 读取每个实体的字段值
+// keys 是用于匹配的值，和 entities 一一对应
+// args 是去重之后的查询参数，NULL 不会出现在里面
+func (p preloader) keysOf(m *model.Model, entities []any,
+	fd *model.Field) (keys []any, args []any, err error) {
+	keys = make([]any, 0, len(entities))
+	set := make(map[any]struct{}, len(entities))
+	for _, entity := range entities {
+		val, err := p.c.creator(m, entity).Field(fd.GoName)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := relationKey(val)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		if key == nil {
+			continue
+		}
+		if _, ok := set[key]; !ok {
+			set[key] = struct{}{}
+			args = append(args, val)
+		}
+	}
+	return keys, args, nil
+}
+
+// query 查询关联表，返回的是指向关联结构体的指针
+func (p preloader) query(ctx context.Context, rel *model.Relation,
+	m *model.Model, col string, args []any) ([]any, error) {
+	q := &preloadQuery{
+		table: m.TableName,
+		col:   col,
+		vals:  args,
+	}
+	if m.SoftDelete != nil && !p.unscoped {
+		q.softDelete = m.SoftDelete.ColName
+	}
+	rows, qc, err := p.rows(ctx, m, q)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var res []any
+	for rows.Next() {
+		tp := reflect.New(rel.Elem).Interface()
+		if err = p.c.creator(m, tp).SetColumns(rows); err != nil {
+			return nil, err
+		}
+		if err = afterFind(ctx, qc, tp); err != nil {
+			return nil, err
+		}
+		res = append(res, tp)
+	}
+	return res, rows.Err()
+}
+
+// rows 关联查询同样经过 middleware
+func (p preloader) rows(ctx context.Context, m *model.Model,
+	q *preloadQuery) (*sql.Rows, *QueryContext, error) {
+	c := p.c
+	c.model = m
+	q.builder = builder{
+		core:   c,
+		quoter: c.dialect.quoter(),
+	}
+	qc := &QueryContext{
+		Type:    "SELECT",
+		Builder: q,
+		Model:   m,
+	}
+	res := iter(ctx, p.sess, c, qc)
+	if res.Err != nil {
+		return nil, nil, res.Err
+	}
+	rows, ok := res.Result.(*sql.Rows)
+	if !ok {
+		return nil, nil, errs.NewErrUnsupportedResult(res.Result)
+	}
+	return rows, qc, nil
+}
+
+// setRelation 把关联数据设置到关联字段上
+// HasMany 和 ManyToMany 即便没有数据也会设置为空切片，用于区分没有加载
+func setRelation(owner any, rel *model.Relation, related []any) error {
+	fd, err := reflect.ValueOf(owner).Elem().FieldByIndexErr(rel.Index)
+	if err != nil {
+		return err
+	}
+	switch rel.Kind {
+	case model.HasOne, model.BelongsTo:
+		if len(related) == 0 {
+			fd.Set(reflect.Zero(fd.Type()))
+			return nil
+		}
+		fd.Set(reflect.ValueOf(related[0]))
+	default:
+		slice := reflect.MakeSlice(fd.Type(), 0, len(related))
+		for _, r := range related {
+			slice = reflect.Append(slice, reflect.ValueOf(r))
+		}
+		fd.Set(slice)
+	}
+	return nil
+}
+
+// primaryKey 关联查询使用的主键
+// 没有声明主键的时候，按照惯例使用 Id 字段
+func primaryKey(m *model.Model) (*model.Field, error) {
+	switch len(m.PrimaryKeys) {
+	case 0:
+		if fd, ok := m.FieldMap["Id"]; ok {
+			return fd, nil
+		}
+	case 1:
+		return m.PrimaryKeys[0], nil
+	}
+	return nil, errs.NewErrNoPrimaryKey(m.TableName)
+}
+
+// relationKey 把字段值转换成可以用于比较的形式
+// 例如 int 和 int64 都会转换成 int64，sql.NullInt64 会转换成 int64 或者 nil
+func relationKey(val any) (any, error) {
+	key, err := driver.DefaultParameterConverter.ConvertValue(val)
+	if err != nil {
+		return nil, err
+	}
+	// []byte 不能作为 map 的 key
+	if bs, ok := key.([]byte); ok {
+		return string(bs), nil
+	}
+	return key, nil
+}
@@ -0,0 +1,176 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector_Preload(t *testing.T) {
+	testCases := []struct {
+		name string
+		// 设置 mock 的预期，并且返回查询
+		query func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error)
+
+		wantRes []*PreloadUser
+		wantErr error
+	}{
+		{
+			name: "has many",
+			query: func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(1, "Tom").AddRow(2, "Jerry").AddRow(3, "DaMing"))
+				mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `user_id` IN (?,?,?);").
+					WithArgs(int64(1), int64(2), int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(11, 1).AddRow(12, 1).AddRow(21, 2))
+				return NewSelector[PreloadUser](db).Preload("Orders").GetMulti(context.Background())
+			},
+			wantRes: []*PreloadUser{
+				{Id: 1, Name: "Tom", Orders: []*PreloadOrder{{Id: 11, UserId: 1}, {Id: 12, UserId: 1}}},
+				{Id: 2, Name: "Jerry", Orders: []*PreloadOrder{{Id: 21, UserId: 2}}},
+				{Id: 3, Name: "DaMing", Orders: []*PreloadOrder{}},
+			},
+		},
+		{
+			name: "has one",
+			query: func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(1, "Tom").AddRow(2, "Jerry"))
+				mock.ExpectQuery("SELECT * FROM `preload_profile` WHERE `user_id` IN (?,?);").
+					WithArgs(int64(1), int64(2)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "bio"}).
+						AddRow(100, 2, "hello"))
+				return NewSelector[PreloadUser](db).Preload("Profile").GetMulti(context.Background())
+			},
+			wantRes: []*PreloadUser{
+				{Id: 1, Name: "Tom"},
+				{Id: 2, Name: "Jerry", Profile: &PreloadProfile{Id: 100, UserId: 2, Bio: "hello"}},
+			},
+		},
+		{
+			name: "many to many",
+			query: func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(1, "Tom").AddRow(2, "Jerry"))
+				mock.ExpectQuery("SELECT `user_id`,`role_id` FROM `user_role` WHERE `user_id` IN (?,?);").
+					WithArgs(int64(1), int64(2)).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}).
+						AddRow(1, 7).AddRow(1, 8).AddRow(2, 7))
+				mock.ExpectQuery("SELECT * FROM `preload_role` WHERE `id` IN (?,?);").
+					WithArgs(int64(7), int64(8)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(7, "admin").AddRow(8, "guest"))
+				return NewSelector[PreloadUser](db).Preload("Roles").GetMulti(context.Background())
+			},
+			wantRes: []*PreloadUser{
+				{Id: 1, Name: "Tom", Roles: []*PreloadRole{{Id: 7, Name: "admin"}, {Id: 8, Name: "guest"}}},
+				{Id: 2, Name: "Jerry", Roles: []*PreloadRole{{Id: 7, Name: "admin"}}},
+			},
+		},
+		{
+			name: "no rows",
+			query: func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+				return NewSelector[PreloadUser](db).Preload("Orders").GetMulti(context.Background())
+			},
+			wantRes: []*PreloadUser{},
+		},
+		{
+			name: "unknown relation",
+			query: func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+				return NewSelector[PreloadUser](db).Preload("Invalid").GetMulti(context.Background())
+			},
+			wantErr: errs.NewErrUnknownRelation("Invalid"),
+		},
+		{
+			name: "preload error",
+			query: func(mock sqlmock.Sqlmock, db *DB) ([]*PreloadUser, error) {
+				mock.ExpectQuery("SELECT * FROM `preload_user`;").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+				mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `user_id` IN (?);").
+					WillReturnError(errors.New("preload error"))
+				return NewSelector[PreloadUser](db).Preload("Orders").GetMulti(context.Background())
+			},
+			wantErr: errors.New("preload error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			res, err := tc.query(mock, db)
+			assert.Equal(t, tc.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestSelector_Preload_BelongsTo(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT * FROM `preload_order` WHERE `id` = ? LIMIT ?;").
+		WithArgs(11, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(11, 1))
+	mock.ExpectQuery("SELECT * FROM `preload_user` WHERE `id` IN (?);").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+
+	res, err := NewSelector[PreloadOrder](db).Where(C("Id").Eq(11)).
+		Limit(1).Preload("User").Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &PreloadOrder{
+		Id:     11,
+		UserId: 1,
+		User:   &PreloadUser{Id: 1, Name: "Tom"},
+	}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type PreloadUser struct {
+	Id      int64
+	Name    string
+	Orders  []*PreloadOrder `orm:"rel=has_many,fk=user_id"`
+	Profile *PreloadProfile `orm:"rel=has_one,fk=user_id"`
+	Roles   []*PreloadRole  `orm:"rel=many_to_many,join=user_role,fk=user_id,ref=role_id"`
+}
+
+type PreloadOrder struct {
+	Id     int64
+	UserId int64
+	User   *PreloadUser `orm:"rel=belongs_to,fk=user_id"`
+}
+
+type PreloadProfile struct {
+	Id     int64
+	UserId int64
+	Bio    string
+}
+
+type PreloadRole struct {
+	Id   int64
+	Name string
+}
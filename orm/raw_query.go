@@ -80,6 +80,18 @@ func (s RawQuerier[T]) Get(ctx context.Context) (*T, error) {
 }
 
 func (r RawQuerier[T]) GetMulti(ctx context.Context) ([]*T, error) {
-	// TODO implement me
-	panic("implement me")
+	var err error
+	r.model, err = r.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	res := getMulti[T](ctx, r.sess, r.core, &QueryContext{
+		Type:    "RAW",
+		Builder: r,
+		Model:   r.model,
+	})
+	if res.Result != nil {
+		return res.Result.([]*T), res.Err
+	}
+	return nil, res.Err
 }
@@ -1,6 +1,10 @@
 package orm
 
-import "database/sql"
+import (
+	"database/sql"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+)
 
 type Result struct {
 	err error
@@ -24,3 +28,29 @@ func (r Result) RowsAffected() (int64, error) {
 func (r Result) Err() error {
 	return r.err
 }
+
+var _ sql.Result = multiResult{}
+
+// multiResult 合并多条语句的执行结果
+type multiResult struct {
+	results []sql.Result
+}
+
+func (r multiResult) LastInsertId() (int64, error) {
+	if len(r.results) != 1 {
+		return 0, errs.ErrMultiLastInsertId
+	}
+	return r.results[0].LastInsertId()
+}
+
+func (r multiResult) RowsAffected() (int64, error) {
+	var sum int64
+	for _, res := range r.results {
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return sum, err
+		}
+		sum += affected
+	}
+	return sum, nil
+}
@@ -18,6 +18,15 @@ type Selector[T any] struct {
 	table   TableReference
 	where   []Predicate
 	columns []Selectable
+	groupBy []Column
+	having  []Predicate
+	orderBy []OrderBy
+	offset  int
+	limit   int
+	// preloads 需要加载的关联字段
+	preloads []string
+	// unscoped 为 true 的时候不过滤软删除的数据
+	unscoped bool
 
 	sess Session
 }
@@ -42,6 +51,7 @@ func (s *Selector[T]) Build() (*Query, error) {
 		}
 	}
 
+	s.reset()
 	s.sb.WriteString("SELECT ")
 
 	if err := s.buildColumns(); err != nil {
@@ -71,17 +81,72 @@ func (s *Selector[T]) Build() (*Query, error) {
 	// 	s.sb.WriteString(s.table)
 	// }
 
-	if len(s.where) > 0 {
+	where := s.where
+	if !s.unscoped {
+		p, ok, err := s.notDeleted(s.table)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			// 不能直接 append，避免修改用户传入的切片
+			where = append(where[:len(where):len(where)], p)
+		}
+	}
+	if len(where) > 0 {
 		s.sb.WriteString(" WHERE ")
-		p := s.where[0]
-		for i := 1; i < len(s.where); i++ {
-			p = p.And(s.where[i])
+		if err := s.buildPredicates(where); err != nil {
+			return nil, err
 		}
-		if err := s.buildExpression(p); err != nil {
+	}
+
+	if len(s.groupBy) > 0 {
+		s.sb.WriteString(" GROUP BY ")
+		for i, c := range s.groupBy {
+			if i > 0 {
+				s.sb.WriteByte(',')
+			}
+			if err := s.buildColumn(Column{name: c.name, table: c.table}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(s.having) > 0 {
+		s.sb.WriteString(" HAVING ")
+		if err := s.buildPredicates(s.having); err != nil {
 			return nil, err
 		}
 	}
 
+	if len(s.orderBy) > 0 {
+		s.sb.WriteString(" ORDER BY ")
+		for i, ob := range s.orderBy {
+			if i > 0 {
+				s.sb.WriteByte(',')
+			}
+			if err := s.buildColumn(Column{name: ob.col}); err != nil {
+				return nil, err
+			}
+			s.sb.WriteByte(' ')
+			s.sb.WriteString(ob.order)
+		}
+	}
+
+	if s.limit > 0 {
+		s.sb.WriteString(" LIMIT ")
+		s.parameter(s.limit)
+	} else if s.offset > 0 {
+		if noLimit := s.dialect.noLimit(); noLimit != "" {
+			s.sb.WriteString(" LIMIT ")
+			s.sb.WriteString(noLimit)
+		}
+	}
+
+	if s.offset > 0 {
+		s.sb.WriteString(" OFFSET ")
+		s.parameter(s.offset)
+	}
+
 	s.sb.WriteByte(';')
 	return &Query{
 		SQL:  s.sb.String(),
@@ -138,11 +203,7 @@ func (s *Selector[T]) buildTable(table TableReference) error {
 
 		if len(t.on) > 0 {
 			s.sb.WriteString(" ON ")
-			p := t.on[0]
-			for i := 1; i < len(t.on); i++ {
-				p = p.And(t.on[i])
-			}
-			if err = s.buildExpression(p); err != nil {
+			if err = s.buildPredicates(t.on); err != nil {
 				return err
 			}
 		}
@@ -154,57 +215,66 @@ func (s *Selector[T]) buildTable(table TableReference) error {
 	return nil
 }
 
-func (s *Selector[T]) buildExpression(expr Expression) error {
-	switch exp := expr.(type) {
-	case nil:
-	case Predicate:
-		// 在这里处理 p
-		// p.left 构建好
-		// p.op 构建好
-		// p.right 构建好
-		_, ok := exp.left.(Predicate)
-		if ok {
-			s.sb.WriteByte('(')
+// tables 查询涉及的所有表，包括 JOIN 两边的表和子查询里面的表
+func (s *Selector[T]) tables() ([]string, error) {
+	if s.model == nil {
+		var err error
+		s.model, err = s.r.Get(new(T))
+		if err != nil {
+			return nil, err
 		}
-		if err := s.buildExpression(exp.left); err != nil {
-			return err
+	}
+	tables, err := s.tableNames(nil, s.table)
+	if err != nil {
+		return nil, err
+	}
+	exprs := make([]Expression, 0, len(s.columns)+len(s.where)+len(s.having))
+	for _, c := range s.columns {
+		if expr, ok := c.(Expression); ok {
+			exprs = append(exprs, expr)
 		}
-		if ok {
-			s.sb.WriteByte(')')
+	}
+	for _, p := range s.where {
+		exprs = append(exprs, p)
+	}
+	for _, p := range s.having {
+		exprs = append(exprs, p)
+	}
+	for _, expr := range exprs {
+		if tables, err = subqueryTables(tables, expr); err != nil {
+			return nil, err
 		}
+	}
+	return tables, nil
+}
 
-		if exp.op != "" {
-			s.sb.WriteByte(' ')
-			s.sb.WriteString(exp.op.String())
-			s.sb.WriteByte(' ')
+func (s *Selector[T]) tableNames(tables []string, table TableReference) ([]string, error) {
+	switch t := table.(type) {
+	case nil:
+		return appendTable(tables, s.model.TableName), nil
+	case Table:
+		m, err := s.r.Get(t.entity)
+		if err != nil {
+			return nil, err
 		}
-
-		_, ok = exp.right.(Predicate)
-		if ok {
-			s.sb.WriteByte('(')
+		return appendTable(tables, m.TableName), nil
+	case Join:
+		tables, err := s.tableNames(tables, t.left)
+		if err != nil {
+			return nil, err
 		}
-		if err := s.buildExpression(exp.right); err != nil {
-			return err
+		if tables, err = s.tableNames(tables, t.right); err != nil {
+			return nil, err
 		}
-		if ok {
-			s.sb.WriteByte(')')
+		for _, p := range t.on {
+			if tables, err = subqueryTables(tables, p); err != nil {
+				return nil, err
+			}
 		}
-	case Column:
-		// 这种写法很隐晦
-		exp.alias = ""
-		return s.buildColumn(exp)
-	case value:
-		s.sb.WriteByte('?')
-		s.addArg(exp.val)
-	case RawExpr:
-		s.sb.WriteByte('(')
-		s.sb.WriteString(exp.raw)
-		s.addArg(exp.args...)
-		s.sb.WriteByte(')')
+		return tables, nil
 	default:
-		return errs.NewErrUnsupportedExpression(expr)
+		return nil, errs.NewErrUnsupportedTable(table)
 	}
-	return nil
 }
 
 func (s *Selector[T]) buildColumns() error {
@@ -226,23 +296,27 @@ func (s *Selector[T]) buildColumns() error {
 					return err
 				}
 			case Aggregate:
-				// 聚合函数名
-				s.sb.WriteString(c.fn)
-				s.sb.WriteByte('(')
-				err := s.buildColumn(Column{name: c.arg})
+				err := s.buildAggregate(c)
 				if err != nil {
 					return err
 				}
-				s.sb.WriteByte(')')
 				// 聚合函数本身的别名
 				if c.alias != "" {
-					s.sb.WriteString(" AS `")
-					s.sb.WriteString(c.alias)
-					s.sb.WriteByte('`')
+					s.sb.WriteString(" AS ")
+					s.quote(c.alias)
 				}
 			case RawExpr:
-				s.sb.WriteString(c.raw)
-				s.addArg(c.args...)
+				s.buildRaw(c)
+			case MathExpr:
+				if err := s.buildExpression(c); err != nil {
+					return err
+				}
+				s.buildAs(c.alias)
+			case FuncExpr:
+				if err := s.buildExpression(c); err != nil {
+					return err
+				}
+				s.buildAs(c.alias)
 			}
 		}
 	}
@@ -292,6 +366,35 @@ func (s *Selector[T]) Where(ps ...Predicate) *Selector[T] {
 	return s
 }
 
+// GroupBy 设置 GROUP BY 子句
+func (s *Selector[T]) GroupBy(cols ...Column) *Selector[T] {
+	s.groupBy = cols
+	return s
+}
+
+// Having 设置 HAVING 子句，只有在 GroupBy 之后才有意义
+// 例如 Having(Avg("Age").GT(18))
+func (s *Selector[T]) Having(ps ...Predicate) *Selector[T] {
+	s.having = ps
+	return s
+}
+
+// OrderBy 例如 OrderBy(Asc("Age"), Desc("Id"))
+func (s *Selector[T]) OrderBy(obs ...OrderBy) *Selector[T] {
+	s.orderBy = obs
+	return s
+}
+
+func (s *Selector[T]) Offset(offset int) *Selector[T] {
+	s.offset = offset
+	return s
+}
+
+func (s *Selector[T]) Limit(limit int) *Selector[T] {
+	s.limit = limit
+	return s
+}
+
 // func (s *Selector[T]) GetV1(ctx context.Context) (*T, error) {
 // 	q, err := s.Build()
 // 	if err != nil {
@@ -347,15 +450,30 @@ func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
 	if err != nil {
 		return nil, err
 	}
+	if sd, ok := s.sess.(*ShardingDB); ok {
+		return s.getSharding(ctx, sd)
+	}
+	tables, err := s.tables()
+	if err != nil {
+		return nil, err
+	}
 	res := get[T](ctx, s.sess, s.core, &QueryContext{
 		Type:    "SELECT",
 		Builder: s,
 		Model:   s.model,
+		Tables:  tables,
 	})
-	if res.Result != nil {
-		return res.Result.(*T), res.Err
+	if res.Result == nil {
+		return nil, res.Err
+	}
+	t := res.Result.(*T)
+	if res.Err != nil {
+		return t, res.Err
+	}
+	if err = s.preload(ctx, []*T{t}); err != nil {
+		return nil, err
 	}
-	return nil, res.Err
+	return t, nil
 }
 
 // func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
@@ -393,5 +511,103 @@ func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
 // }
 
 func (s *Selector[T]) GetMulti(ctx context.Context) ([]*T, error) {
-	panic("implement me")
+	var err error
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if sd, ok := s.sess.(*ShardingDB); ok {
+		return s.getMultiSharding(ctx, sd)
+	}
+	tables, err := s.tables()
+	if err != nil {
+		return nil, err
+	}
+	res := getMulti[T](ctx, s.sess, s.core, &QueryContext{
+		Type:    "SELECT",
+		Builder: s,
+		Model:   s.model,
+		Tables:  tables,
+	})
+	if res.Result == nil {
+		return nil, res.Err
+	}
+	ts := res.Result.([]*T)
+	if res.Err != nil {
+		return ts, res.Err
+	}
+	if err = s.preload(ctx, ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Preload 指定需要加载的关联字段，例如 Preload("Orders")
+// 每个关联字段会额外发起一次 IN 查询，而不是每一行查询一次
+// Iter 不支持 Preload
+func (s *Selector[T]) Preload(names ...string) *Selector[T] {
+	s.preloads = append(s.preloads, names...)
+	return s
+}
+
+// Unscoped 查询包括已经被软删除的数据
+func (s *Selector[T]) Unscoped() *Selector[T] {
+	s.unscoped = true
+	return s
+}
+
+func (s *Selector[T]) preload(ctx context.Context, ts []*T) error {
+	if len(s.preloads) == 0 || len(ts) == 0 {
+		return nil
+	}
+	owners := make([]any, 0, len(ts))
+	for _, t := range ts {
+		owners = append(owners, t)
+	}
+	p := preloader{
+		c:        s.core,
+		sess:     s.sess,
+		unscoped: s.unscoped,
+	}
+	for _, name := range s.preloads {
+		rel, ok := s.model.RelationMap[name]
+		if !ok {
+			return errs.NewErrUnknownRelation(name)
+		}
+		if err := p.load(ctx, rel, owners); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Iter 返回一个迭代器，逐行读取结果集
+// 适用于结果集非常大，不能一次性加载到内存的场景
+// 用完之后一定要调用 Iterator.Close
+func (s *Selector[T]) Iter(ctx context.Context) *Iterator[T] {
+	var err error
+	s.model, err = s.r.Get(new(T))
+	if err != nil {
+		return &Iterator[T]{err: err}
+	}
+	tables, err := s.tables()
+	if err != nil {
+		return &Iterator[T]{err: err}
+	}
+	qc := &QueryContext{
+		Type:    "SELECT",
+		Builder: s,
+		Model:   s.model,
+		Tables:  tables,
+	}
+	res := iter(ctx, s.sess, s.core, qc)
+	return newIterator[T](ctx, s.core, qc, res)
+}
+
+// AsSubquery 把当前查询作为子查询
+// 例如 C("Id").In(NewSelector[Order](db).Select(C("UserId")).AsSubquery())
+func (s *Selector[T]) AsSubquery() Subquery {
+	return Subquery{
+		s: s,
+	}
 }
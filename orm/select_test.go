@@ -215,6 +215,27 @@ func TestSelector_Select(t *testing.T) {
 				SQL: "SELECT MIN(`age`),MAX(`age`) FROM `test_model`;",
 			},
 		},
+		{
+			name: "math expression",
+			s:    NewSelector[TestModel](db).Select(C("Age").Add(1).Multiply(2).As("double_age"), C("Id").Divide(C("Age"))),
+			wantQuery: &Query{
+				SQL:  "SELECT (`age` + ?) * ? AS `double_age`,`id` / `age` FROM `test_model`;",
+				Args: []any{1, 2},
+			},
+		},
+		{
+			name: "function",
+			s:    NewSelector[TestModel](db).Select(Fn("COALESCE", C("LastName"), "anon").As("name")),
+			wantQuery: &Query{
+				SQL:  "SELECT COALESCE(`last_name`,?) AS `name` FROM `test_model`;",
+				Args: []any{"anon"},
+			},
+		},
+		{
+			name:    "function invalid column",
+			s:       NewSelector[TestModel](db).Select(Fn("UPPER", C("Invalid"))),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
 		{
 			name: "raw expression",
 			s:    NewSelector[TestModel](db).Select(Raw("COUNT(DISTINCT `first_name`)")),
@@ -322,6 +343,273 @@ func TestSelector_Build(t *testing.T) {
 				Args: []any{18},
 			},
 		},
+		{
+			name: "comparison",
+			builder: NewSelector[TestModel](db).Where(C("Age").GT(18), C("Age").LE(30),
+				C("Id").GE(10), C("Id").NEQ(12)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (((`age` > ?) AND (`age` <= ?)) AND (`id` >= ?)) AND (`id` != ?);",
+				Args: []any{18, 30, 10, 12},
+			},
+		},
+		{
+			name:    "math expression in where",
+			builder: NewSelector[TestModel](db).Where(C("Id").GT(C("Age").Sub(1))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` > (`age` - ?);",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "function in where",
+			builder: NewSelector[TestModel](db).Where(C("FirstName").Eq(Fn("LOWER", C("LastName")))),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE `first_name` = LOWER(`last_name`);",
+			},
+		},
+		{
+			name:    "math expression predicate",
+			builder: NewSelector[TestModel](db).Where(C("Age").Add(1).GT(18).And(C("Age").Multiply(2).LE(C("Id")))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE ((`age` + ?) > ?) AND ((`age` * ?) <= `id`);",
+				Args: []any{1, 18, 2},
+			},
+		},
+		{
+			name:    "function predicate",
+			builder: NewSelector[TestModel](db).Where(Fn("LOWER", C("FirstName")).Eq("tom").Or(Fn("LENGTH", C("FirstName")).LT(3))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (LOWER(`first_name`) = ?) OR (LENGTH(`first_name`) < ?);",
+				Args: []any{"tom", 3},
+			},
+		},
+		{
+			name: "math expression predicate in having",
+			builder: NewSelector[TestModel](db).Select(C("Age")).GroupBy(C("Age")).
+				Having(Fn("MAX", C("Id")).NEQ(0), C("Age").Sub(1).GE(17)),
+			wantQuery: &Query{
+				SQL:  "SELECT `age` FROM `test_model` GROUP BY `age` HAVING (MAX(`id`) != ?) AND ((`age` - ?) >= ?);",
+				Args: []any{0, 1, 17},
+			},
+		},
+		{
+			name:    "in",
+			builder: NewSelector[TestModel](db).Where(C("Id").In(1, 2, 3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?,?);",
+				Args: []any{1, 2, 3},
+			},
+		},
+		{
+			name:    "not in",
+			builder: NewSelector[TestModel](db).Where(C("Id").NotIn(1, 2).And(C("Age").Eq(18))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`id` NOT IN (?,?)) AND (`age` = ?);",
+				Args: []any{1, 2, 18},
+			},
+		},
+		{
+			name:    "empty in",
+			builder: NewSelector[TestModel](db).Where(C("Id").In()),
+			wantErr: errs.ErrEmptyValues,
+		},
+		{
+			name: "in subquery",
+			builder: NewSelector[TestModel](db).Where(C("Id").In(
+				NewSelector[TestModel](db).Select(C("Id")).Where(C("Age").GT(18)).AsSubquery(),
+			).And(C("FirstName").Eq("Tom"))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`id` IN (SELECT `id` FROM `test_model` WHERE `age` > ?)) AND (`first_name` = ?);",
+				Args: []any{18, "Tom"},
+			},
+		},
+		{
+			name:    "invalid subquery",
+			builder: NewSelector[TestModel](db).Where(C("Id").NotIn(NewSelector[TestModel](db).Select(C("Invalid")).AsSubquery())),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name:    "like",
+			builder: NewSelector[TestModel](db).Where(C("FirstName").Like("Tom%").Or(C("LastName").NotLike("%Jerry"))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`first_name` LIKE ?) OR (`last_name` NOT LIKE ?);",
+				Args: []any{"Tom%", "%Jerry"},
+			},
+		},
+		{
+			name:    "between",
+			builder: NewSelector[TestModel](db).Where(C("Age").Between(18, 30)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` BETWEEN ? AND ?;",
+				Args: []any{18, 30},
+			},
+		},
+		{
+			name:    "is null",
+			builder: NewSelector[TestModel](db).Where(C("LastName").IsNull().Or(C("FirstName").IsNotNull())),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`last_name` IS NULL) OR (`first_name` IS NOT NULL);",
+			},
+		},
+		{
+			name:    "not is null",
+			builder: NewSelector[TestModel](db).Where(Not(C("LastName").IsNull())),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE  NOT (`last_name` IS NULL);",
+			},
+		},
+		{
+			name:    "group by",
+			builder: NewSelector[TestModel](db).GroupBy(C("Age"), C("FirstName")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` GROUP BY `age`,`first_name`;",
+			},
+		},
+		{
+			name:    "group by invalid column",
+			builder: NewSelector[TestModel](db).GroupBy(C("Invalid")),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name: "having",
+			builder: NewSelector[TestModel](db).Select(C("Age"), Avg("Id").As("avg_id")).
+				GroupBy(C("Age")).Having(C("Age").Eq(18)),
+			wantQuery: &Query{
+				SQL:  "SELECT `age`,AVG(`id`) AS `avg_id` FROM `test_model` GROUP BY `age` HAVING `age` = ?;",
+				Args: []any{18},
+			},
+		},
+		{
+			name: "having aggregate",
+			builder: NewSelector[TestModel](db).GroupBy(C("FirstName")).
+				Having(Avg("Age").GT(18).And(Count("Id").LT(10))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` GROUP BY `first_name` HAVING (AVG(`age`) > ?) AND (COUNT(`id`) < ?);",
+				Args: []any{18, 10},
+			},
+		},
+		{
+			name:    "having invalid aggregate",
+			builder: NewSelector[TestModel](db).GroupBy(C("FirstName")).Having(Avg("Invalid").Eq(18)),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name:    "order by",
+			builder: NewSelector[TestModel](db).OrderBy(Asc("Age"), Desc("Id")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` ORDER BY `age` ASC,`id` DESC;",
+			},
+		},
+		{
+			name:    "order by invalid column",
+			builder: NewSelector[TestModel](db).OrderBy(Asc("Invalid")),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name:    "limit offset",
+			builder: NewSelector[TestModel](db).Where(C("Age").Eq(18)).Offset(20).Limit(10),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `age` = ? LIMIT ? OFFSET ?;",
+				Args: []any{18, 10, 20},
+			},
+		},
+		{
+			// MySQL 不能只有 OFFSET
+			name:    "offset only",
+			builder: NewSelector[TestModel](db).Offset(20),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` LIMIT 18446744073709551615 OFFSET ?;",
+				Args: []any{20},
+			},
+		},
+		{
+			name: "all clauses",
+			builder: NewSelector[TestModel](db).Where(C("Id").LT(100)).
+				GroupBy(C("Age")).Having(Count("Id").GT(1)).
+				OrderBy(Desc("Age")).Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE `id` < ? GROUP BY `age` " +
+					"HAVING COUNT(`id`) > ? ORDER BY `age` DESC LIMIT ? OFFSET ?;",
+				Args: []any{100, 1, 10, 20},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestSelector_PostgreSQL(t *testing.T) {
+	db := memoryDB(t, DBWithDialect(DialectPostgreSQL))
+	testCases := []struct {
+		name string
+
+		builder QueryBuilder
+
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "no where",
+			builder: NewSelector[TestModel](db).Select(C("FirstName").As("name")),
+			wantQuery: &Query{
+				SQL: `SELECT "first_name" AS "name" FROM "test_model";`,
+			},
+		},
+		{
+			name: "where",
+			builder: NewSelector[TestModel](db).
+				Where(C("Age").Eq(18).And(C("FirstName").Eq("Tom"))),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE ("age" = $1) AND ("first_name" = $2);`,
+				Args: []any{18, "Tom"},
+			},
+		},
+		{
+			name: "in subquery",
+			builder: NewSelector[TestModel](db).Where(C("Age").Eq(18), C("Id").In(
+				NewSelector[TestModel](db).Select(C("Id")).Where(C("FirstName").In("Tom", "Jerry")).AsSubquery(),
+			), C("LastName").Eq("Deng")),
+			wantQuery: &Query{
+				SQL: `SELECT * FROM "test_model" WHERE (("age" = $1) AND ("id" IN (SELECT "id" FROM "test_model" WHERE "first_name" IN ($2,$3)))) ` +
+					`AND ("last_name" = $4);`,
+				Args: []any{18, "Tom", "Jerry", "Deng"},
+			},
+		},
+		{
+			name: "having limit offset",
+			builder: NewSelector[TestModel](db).Where(C("Id").Eq(1)).
+				GroupBy(C("Age")).Having(Count("Id").GT(2)).
+				Limit(10).Offset(20),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE "id" = $1 GROUP BY "age" HAVING COUNT("id") > $2 LIMIT $3 OFFSET $4;`,
+				Args: []any{1, 2, 10, 20},
+			},
+		},
+		{
+			name: "raw expression",
+			builder: NewSelector[TestModel](db).Select(Raw("COUNT(?)", 1)).
+				Where(C("Id").Eq(1), Raw(`"age" > ?`, 18).AsPredicate()),
+			wantQuery: &Query{
+				SQL:  `SELECT COUNT($1) FROM "test_model" WHERE ("id" = $2) AND (("age" > $3));`,
+				Args: []any{1, 1, 18},
+			},
+		},
+		{
+			name:    "offset only",
+			builder: NewSelector[TestModel](db).Offset(20),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" OFFSET $1;`,
+				Args: []any{20},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -335,6 +623,26 @@ func TestSelector_Build(t *testing.T) {
 	}
 }
 
+func TestSelector_OffsetSQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:offset.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec("CREATE TABLE savepoint_model(id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	ctx := context.Background()
+	err = NewInserter[SavepointModel](db).
+		Values(&SavepointModel{Id: 1}, &SavepointModel{Id: 2}, &SavepointModel{Id: 3}).Exec(ctx).Err()
+	require.NoError(t, err)
+
+	q, err := NewSelector[SavepointModel](db).Offset(1).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `savepoint_model` LIMIT -1 OFFSET ?;", q.SQL)
+	res, err := NewSelector[SavepointModel](db).OrderBy(Asc("Id")).Offset(1).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*SavepointModel{{Id: 2}, {Id: 3}}, res)
+}
+
 type TestModel struct {
 	Id int64
 	// ""
@@ -428,6 +736,105 @@ func TestSelector_Get(t *testing.T) {
 	}
 }
 
+func TestSelector_GetMulti(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// 对应于 query error
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("query error"))
+
+	// 对应于 no rows
+	rows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	// data
+	rows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	rows.AddRow("1", "Tom", "18", "Jerry")
+	rows.AddRow("2", "Daming", "19", "Deng")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	// scan error
+	rows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	rows.AddRow("1", "Tom", "18", "Jerry")
+	rows.AddRow("abc", "Daming", "19", "Deng")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	// rows error
+	rows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	rows.AddRow("1", "Tom", "18", "Jerry")
+	rows.AddRow("2", "Daming", "19", "Deng")
+	rows.RowError(1, errors.New("rows error"))
+	mock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	testCases := []struct {
+		name string
+		s    *Selector[TestModel]
+
+		wantErr error
+		wantRes []*TestModel
+	}{
+		{
+			name:    "invalid query",
+			s:       NewSelector[TestModel](db).Where(C("XXX").Eq(1)),
+			wantErr: errs.NewErrUnknownField("XXX"),
+		},
+		{
+			name:    "query error",
+			s:       NewSelector[TestModel](db),
+			wantErr: errors.New("query error"),
+		},
+		{
+			name:    "no rows",
+			s:       NewSelector[TestModel](db),
+			wantRes: []*TestModel{},
+		},
+		{
+			name: "data",
+			s:    NewSelector[TestModel](db),
+			wantRes: []*TestModel{
+				{
+					Id:        1,
+					FirstName: "Tom",
+					Age:       18,
+					LastName:  &sql.NullString{Valid: true, String: "Jerry"},
+				},
+				{
+					Id:        2,
+					FirstName: "Daming",
+					Age:       19,
+					LastName:  &sql.NullString{Valid: true, String: "Deng"},
+				},
+			},
+		},
+		{
+			name: "scan error",
+			s:    NewSelector[TestModel](db),
+			wantErr: errors.New("sql: Scan error on column index 0, name \"id\": " +
+				"converting driver.Value type string (\"abc\") to a int64: invalid syntax"),
+		},
+		{
+			name:    "rows error",
+			s:       NewSelector[TestModel](db),
+			wantErr: errors.New("rows error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.s.GetMulti(context.Background())
+			if tc.wantErr != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.wantErr.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
 func memoryDB(t *testing.T, opts ...DBOption) *DB {
 	db, err := Open("sqlite3",
 		"file:test.db?cache=shared&mode=memory",
@@ -0,0 +1,600 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/valuer"
+	"github.com/jackycsl/geektime-go-practical/orm/model"
+)
+
+var _ Session = &ShardingDB{}
+
+// ShardingTable 由实体实现，声明分库分表的规则
+//
+//	func (Order) ShardingRule() ShardingRule {
+//		return ShardingRule{Key: "UserId", Algorithm: &HashSharding{...}}
+//	}
+type ShardingTable interface {
+	ShardingRule() ShardingRule
+}
+
+type ShardingRule struct {
+	// Key 分片键对应的字段名，例如 UserId
+	Key       string
+	Algorithm ShardingAlgorithm
+}
+
+// Dst 分库分表的目标，DB 对应 NewShardingDB 里面的 key
+type Dst struct {
+	DB    string
+	Table string
+}
+
+type ShardingAlgorithm interface {
+	// Sharding 计算分片键的值对应的目标
+	Sharding(key any) (Dst, error)
+	// Broadcast 返回所有的目标，查询条件里面没有分片键的时候使用
+	Broadcast() []Dst
+}
+
+// HashSharding 按照分片键的哈希值分库分表
+// 一共有 DBCount * TableCount 张表，每个库里面的表名是一样的
+// 例如 DBPattern 为 "order_db_%d"，TablePattern 为 "order_%02d"
+// 整数直接取模，字符串使用 FNV-1a 哈希
+type HashSharding struct {
+	DBPattern    string
+	DBCount      int
+	TablePattern string
+	TableCount   int
+}
+
+func (h *HashSharding) Sharding(key any) (Dst, error) {
+	hash, err := shardingHash(key)
+	if err != nil {
+		return Dst{}, err
+	}
+	dbCnt, tblCnt := uint64(h.dbCount()), uint64(h.tableCount())
+	slot := hash % (dbCnt * tblCnt)
+	return h.dst(int(slot/tblCnt), int(slot%tblCnt)), nil
+}
+
+func (h *HashSharding) Broadcast() []Dst {
+	res := make([]Dst, 0, h.dbCount()*h.tableCount())
+	for i := 0; i < h.dbCount(); i++ {
+		for j := 0; j < h.tableCount(); j++ {
+			res = append(res, h.dst(i, j))
+		}
+	}
+	return res
+}
+
+func (h *HashSharding) dst(db, table int) Dst {
+	return Dst{
+		DB:    shardingName(h.DBPattern, h.dbCount(), db),
+		Table: shardingName(h.TablePattern, h.tableCount(), table),
+	}
+}
+
+func (h *HashSharding) dbCount() int {
+	if h.DBCount < 1 {
+		return 1
+	}
+	return h.DBCount
+}
+
+func (h *HashSharding) tableCount() int {
+	if h.TableCount < 1 {
+		return 1
+	}
+	return h.TableCount
+}
+
+// shardingName 只有一个库或者一张表的时候，pattern 就是名字本身
+func shardingName(pattern string, cnt int, idx int) string {
+	if cnt <= 1 {
+		return pattern
+	}
+	return fmt.Sprintf(pattern, idx)
+}
+
+func shardingHash(key any) (uint64, error) {
+	val := reflect.ValueOf(key)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(val.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return val.Uint(), nil
+	case reflect.String:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(val.String()))
+		return h.Sum64(), nil
+	}
+	return 0, errs.NewErrUnsupportedShardingKey(key)
+}
+
+// RangeSharding 按照分片键的范围分库分表，只支持整数分片键
+type RangeSharding struct {
+	Ranges []ShardingRange
+}
+
+// ShardingRange 左闭右开 [Start, End)
+type ShardingRange struct {
+	Start int64
+	End   int64
+	Dst   Dst
+}
+
+func (r *RangeSharding) Sharding(key any) (Dst, error) {
+	var k int64
+	val := reflect.ValueOf(key)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		k = val.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		k = int64(val.Uint())
+	default:
+		return Dst{}, errs.NewErrUnsupportedShardingKey(key)
+	}
+	for _, rg := range r.Ranges {
+		if k >= rg.Start && k < rg.End {
+			return rg.Dst, nil
+		}
+	}
+	return Dst{}, errs.NewErrNoShardingRange(key)
+}
+
+func (r *RangeSharding) Broadcast() []Dst {
+	res := make([]Dst, 0, len(r.Ranges))
+	for _, rg := range r.Ranges {
+		res = union(res, []Dst{rg.Dst})
+	}
+	return res
+}
+
+// ShardingDB 分库分表，dbs 的 key 是 Dst.DB，value 可以是 *DB 或者 *Cluster
+// 只有实现了 ShardingTable 的实体可以通过 Selector 和 Inserter 使用它
+// Selector 的表名会被改写，所以 From 指定的表、子查询和 Preload 都不支持分库分表
+type ShardingDB struct {
+	core
+	dbs map[string]Session
+}
+
+// NewShardingDB 方言、元数据注册中心和 middleware 通过 opts 指定，
+// dbs 自身的这些配置会被忽略
+func NewShardingDB(dbs map[string]Session, opts ...DBOption) *ShardingDB {
+	db := &DB{
+		core: core{
+			r:       model.NewRegistry(),
+			creator: valuer.NewUnsafeValue,
+			dialect: DialectMySQL,
+		},
+	}
+	for _, opt := range opts {
+		opt(db)
+	}
+	return &ShardingDB{
+		core: db.core,
+		dbs:  dbs,
+	}
+}
+
+func (sd *ShardingDB) getCore() core {
+	return sd.core
+}
+
+// queryContext 不知道目标库，所以不能直接执行 SQL
+func (sd *ShardingDB) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errs.ErrShardingDirectSQL
+}
+
+func (sd *ShardingDB) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, errs.ErrShardingDirectSQL
+}
+
+func (sd *ShardingDB) session(dst Dst) (Session, error) {
+	sess, ok := sd.dbs[dst.DB]
+	if !ok {
+		return nil, errs.NewErrUnknownShardingDB(dst.DB)
+	}
+	return sess, nil
+}
+
+// fanOut 在每个目标上并发执行 fn，返回的结果和 dsts 一一对应
+func (sd *ShardingDB) fanOut(dsts []Dst, fn func(sess Session, dst Dst) *QueryResult) []*QueryResult {
+	res := make([]*QueryResult, len(dsts))
+	var wg sync.WaitGroup
+	for i, dst := range dsts {
+		sess, err := sd.session(dst)
+		if err != nil {
+			res[i] = &QueryResult{Err: err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, sess Session, dst Dst) {
+			defer wg.Done()
+			res[i] = fn(sess, dst)
+		}(i, sess, dst)
+	}
+	wg.Wait()
+	return res
+}
+
+func shardingRuleOf(m *model.Model, entity any) (ShardingRule, error) {
+	st, ok := entity.(ShardingTable)
+	if !ok {
+		return ShardingRule{}, errs.NewErrNotShardingTable(m.TableName)
+	}
+	rule := st.ShardingRule()
+	if _, ok = m.FieldMap[rule.Key]; !ok {
+		return ShardingRule{}, errs.NewErrUnknownField(rule.Key)
+	}
+	return rule, nil
+}
+
+// dsts 根据 WHERE 条件计算需要查询的目标
+// 只识别分片键的 = 和 IN，以及它们的 AND、OR 组合，其余情况都要广播
+func (r ShardingRule) dsts(ps []Predicate) ([]Dst, error) {
+	if len(ps) == 0 {
+		return r.Algorithm.Broadcast(), nil
+	}
+	p := ps[0]
+	for _, q := range ps[1:] {
+		p = p.And(q)
+	}
+	dsts, ok, err := r.route(p)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return r.Algorithm.Broadcast(), nil
+	}
+	return dsts, nil
+}
+
+// route 返回的 bool 代表能不能根据 p 确定目标
+func (r ShardingRule) route(p Predicate) ([]Dst, bool, error) {
+	switch p.op {
+	case opAnd, opOr:
+		left, lok, err := r.routeExpr(p.left)
+		if err != nil {
+			return nil, false, err
+		}
+		right, rok, err := r.routeExpr(p.right)
+		if err != nil {
+			return nil, false, err
+		}
+		if p.op == opOr {
+			if lok && rok {
+				return union(left, right), true, nil
+			}
+			return nil, false, nil
+		}
+		switch {
+		case lok && rok:
+			return intersect(left, right), true, nil
+		case lok:
+			return left, true, nil
+		case rok:
+			return right, true, nil
+		}
+	case opEq:
+		val, ok := p.right.(value)
+		if !ok || !r.isKey(p.left) {
+			return nil, false, nil
+		}
+		dst, err := r.Algorithm.Sharding(val.val)
+		if err != nil {
+			return nil, false, err
+		}
+		return []Dst{dst}, true, nil
+	case opIn:
+		vals, ok := p.right.(values)
+		if !ok || !r.isKey(p.left) {
+			return nil, false, nil
+		}
+		res := make([]Dst, 0, len(vals))
+		for _, val := range vals {
+			dst, err := r.Algorithm.Sharding(val)
+			if err != nil {
+				return nil, false, err
+			}
+			res = union(res, []Dst{dst})
+		}
+		return res, true, nil
+	}
+	return nil, false, nil
+}
+
+func (r ShardingRule) routeExpr(expr Expression) ([]Dst, bool, error) {
+	p, ok := expr.(Predicate)
+	if !ok {
+		return nil, false, nil
+	}
+	return r.route(p)
+}
+
+func (r ShardingRule) isKey(expr Expression) bool {
+	col, ok := expr.(Column)
+	return ok && col.table == nil && col.name == r.Key
+}
+
+func union(left, right []Dst) []Dst {
+	// 不能直接 append，避免修改 left
+	res := left[:len(left):len(left)]
+	for _, dst := range right {
+		if !containsDst(res, dst) {
+			res = append(res, dst)
+		}
+	}
+	return res
+}
+
+func intersect(left, right []Dst) []Dst {
+	res := make([]Dst, 0, len(left))
+	for _, dst := range left {
+		if containsDst(right, dst) {
+			res = append(res, dst)
+		}
+	}
+	return res
+}
+
+func containsDst(dsts []Dst, dst Dst) bool {
+	for _, d := range dsts {
+		if d == dst {
+			return true
+		}
+	}
+	return false
+}
+
+// shard 返回一个新的 builder，表名改写为分片的表名
+func (b *builder) shard(table string) builder {
+	m := *b.model
+	m.TableName = table
+	c := b.core
+	c.model = &m
+	return builder{
+		core:   c,
+		quoter: b.quoter,
+	}
+}
+
+func (s *Selector[T]) shard(table string) *Selector[T] {
+	cp := *s
+	cp.builder = s.builder.shard(table)
+	return &cp
+}
+
+func (s *Selector[T]) getSharding(ctx context.Context, sd *ShardingDB) (*T, error) {
+	rule, err := shardingRuleOf(s.model, new(T))
+	if err != nil {
+		return nil, err
+	}
+	dsts, err := rule.dsts(s.where)
+	if err != nil {
+		return nil, err
+	}
+	results := sd.fanOut(dsts, func(sess Session, dst Dst) *QueryResult {
+		cp := s.shard(dst.Table)
+		return get[T](ctx, sess, cp.core, &QueryContext{
+			Type:    "SELECT",
+			Builder: cp,
+			Model:   cp.model,
+		})
+	})
+	// 按照目标的顺序，取第一个查到的
+	var t *T
+	for _, res := range results {
+		if res.Err == ErrNoRows {
+			continue
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		if t == nil {
+			t = res.Result.(*T)
+		}
+	}
+	if t == nil {
+		return nil, ErrNoRows
+	}
+	if err = s.preload(ctx, []*T{t}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Selector[T]) getMultiSharding(ctx context.Context, sd *ShardingDB) ([]*T, error) {
+	rule, err := shardingRuleOf(s.model, new(T))
+	if err != nil {
+		return nil, err
+	}
+	dsts, err := rule.dsts(s.where)
+	if err != nil {
+		return nil, err
+	}
+	merge := len(dsts) > 1
+	results := sd.fanOut(dsts, func(sess Session, dst Dst) *QueryResult {
+		cp := s.shard(dst.Table)
+		if merge && s.offset > 0 {
+			// 每个分片都取前 offset+limit 行，合并之后再跳过 offset 行
+			cp.offset = 0
+			if cp.limit > 0 {
+				cp.limit += s.offset
+			}
+		}
+		return getMulti[T](ctx, sess, cp.core, &QueryContext{
+			Type:    "SELECT",
+			Builder: cp,
+			Model:   cp.model,
+		})
+	})
+	ts := make([]*T, 0, 8)
+	for _, res := range results {
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		ts = append(ts, res.Result.([]*T)...)
+	}
+	if merge {
+		if ts, err = s.merge(ts); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.preload(ctx, ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// merge 在内存里面重新执行 ORDER BY、OFFSET 和 LIMIT
+// GROUP BY 和聚合函数没办法合并，结果只是简单拼接
+func (s *Selector[T]) merge(ts []*T) ([]*T, error) {
+	if len(s.orderBy) > 0 {
+		fds := make([]*model.Field, 0, len(s.orderBy))
+		for _, ob := range s.orderBy {
+			fd, ok := s.model.FieldMap[ob.col]
+			if !ok {
+				return nil, errs.NewErrUnknownField(ob.col)
+			}
+			fds = append(fds, fd)
+		}
+		sort.SliceStable(ts, func(i, j int) bool {
+			left, right := reflect.ValueOf(ts[i]).Elem(), reflect.ValueOf(ts[j]).Elem()
+			for k, fd := range fds {
+				res := compareField(left, right, fd)
+				if res == 0 {
+					continue
+				}
+				if s.orderBy[k].order == "DESC" {
+					return res > 0
+				}
+				return res < 0
+			}
+			return false
+		})
+	}
+	if s.offset >= len(ts) {
+		return ts[:0], nil
+	}
+	ts = ts[s.offset:]
+	if s.limit > 0 && len(ts) > s.limit {
+		ts = ts[:s.limit]
+	}
+	return ts, nil
+}
+
+func compareField(left, right reflect.Value, fd *model.Field) int {
+	l, err := left.FieldByIndexErr(fd.Index)
+	if err != nil {
+		return 0
+	}
+	r, err := right.FieldByIndexErr(fd.Index)
+	if err != nil {
+		return 0
+	}
+	return compareValue(l, r)
+}
+
+// compareValue 不能比较的类型当作相等
+func compareValue(left, right reflect.Value) int {
+	switch left.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(left.Int(), right.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(left.Uint(), right.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(left.Float(), right.Float())
+	case reflect.String:
+		return compareOrdered(left.String(), right.String())
+	case reflect.Pointer:
+		// NULL 排在前面
+		switch {
+		case left.IsNil() && right.IsNil():
+			return 0
+		case left.IsNil():
+			return -1
+		case right.IsNil():
+			return 1
+		}
+		return compareValue(left.Elem(), right.Elem())
+	case reflect.Struct:
+		l, ok := left.Interface().(time.Time)
+		if !ok {
+			return 0
+		}
+		r := right.Interface().(time.Time)
+		switch {
+		case l.Before(r):
+			return -1
+		case l.After(r):
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareOrdered[V int64 | uint64 | float64 | string](left, right V) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	}
+	return 0
+}
+
+func (i *Inserter[T]) execSharding(ctx context.Context, sd *ShardingDB) Result {
+	if len(i.values) == 0 {
+		return Result{err: errs.ErrInsertZeroRow}
+	}
+	rule, err := shardingRuleOf(i.model, new(T))
+	if err != nil {
+		return Result{err: err}
+	}
+	fd := i.model.FieldMap[rule.Key]
+	// 按照目标分组，组内保持 Values 的顺序
+	dsts := make([]Dst, 0, 1)
+	groups := make(map[Dst][]*T, 1)
+	for _, val := range i.values {
+		key, err := reflect.ValueOf(val).Elem().FieldByIndexErr(fd.Index)
+		if err != nil {
+			return Result{err: err}
+		}
+		dst, err := rule.Algorithm.Sharding(key.Interface())
+		if err != nil {
+			return Result{err: err}
+		}
+		if _, ok := groups[dst]; !ok {
+			dsts = append(dsts, dst)
+		}
+		groups[dst] = append(groups[dst], val)
+	}
+
+	// 每个分片各自执行，多个分片之间不保证原子性
+	res := multiResult{results: make([]sql.Result, 0, len(dsts))}
+	for _, dst := range dsts {
+		sess, err := sd.session(dst)
+		if err != nil {
+			return Result{err: err, res: res}
+		}
+		cp := *i
+		cp.builder = i.builder.shard(dst.Table)
+		cp.values = groups[dst]
+		r := cp.execBatch(ctx, sess)
+		if r.err != nil {
+			return Result{err: r.err, res: res}
+		}
+		res.results = append(res.results, r.res)
+	}
+	return Result{res: res}
+}
@@ -0,0 +1,370 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashSharding(t *testing.T) {
+	h := &HashSharding{
+		DBPattern:    "order_db_%d",
+		DBCount:      2,
+		TablePattern: "order_%02d",
+		TableCount:   4,
+	}
+	testCases := []struct {
+		name string
+		key  any
+
+		wantDst Dst
+		wantErr error
+	}{
+		{
+			name:    "int",
+			key:     3,
+			wantDst: Dst{DB: "order_db_0", Table: "order_03"},
+		},
+		{
+			name:    "second db",
+			key:     int64(13),
+			wantDst: Dst{DB: "order_db_1", Table: "order_01"},
+		},
+		{
+			name:    "uint",
+			key:     uint8(8),
+			wantDst: Dst{DB: "order_db_0", Table: "order_00"},
+		},
+		{
+			name:    "string",
+			key:     "Tom",
+			wantDst: Dst{DB: "order_db_0", Table: "order_03"},
+		},
+		{
+			name:    "unsupported",
+			key:     1.5,
+			wantErr: errs.NewErrUnsupportedShardingKey(1.5),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst, err := h.Sharding(tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantDst, dst)
+		})
+	}
+	assert.Len(t, h.Broadcast(), 8)
+
+	// 只有一个库的时候，DBPattern 就是库名
+	single := &HashSharding{DBPattern: "order_db", TablePattern: "order_%d", TableCount: 2}
+	assert.Equal(t, []Dst{
+		{DB: "order_db", Table: "order_0"},
+		{DB: "order_db", Table: "order_1"},
+	}, single.Broadcast())
+}
+
+func TestRangeSharding(t *testing.T) {
+	r := &RangeSharding{
+		Ranges: []ShardingRange{
+			{Start: 0, End: 1000, Dst: Dst{DB: "db_0", Table: "order_0"}},
+			{Start: 1000, End: 2000, Dst: Dst{DB: "db_0", Table: "order_1"}},
+			{Start: 2000, End: 3000, Dst: Dst{DB: "db_0", Table: "order_1"}},
+		},
+	}
+	testCases := []struct {
+		name string
+		key  any
+
+		wantDst Dst
+		wantErr error
+	}{
+		{
+			name:    "start",
+			key:     1000,
+			wantDst: Dst{DB: "db_0", Table: "order_1"},
+		},
+		{
+			name:    "end",
+			key:     uint(999),
+			wantDst: Dst{DB: "db_0", Table: "order_0"},
+		},
+		{
+			name:    "out of range",
+			key:     3000,
+			wantErr: errs.NewErrNoShardingRange(3000),
+		},
+		{
+			name:    "unsupported",
+			key:     "1000",
+			wantErr: errs.NewErrUnsupportedShardingKey("1000"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst, err := r.Sharding(tc.key)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantDst, dst)
+		})
+	}
+	// 重复的目标只出现一次
+	assert.Equal(t, []Dst{
+		{DB: "db_0", Table: "order_0"},
+		{DB: "db_0", Table: "order_1"},
+	}, r.Broadcast())
+}
+
+func TestShardingRule_dsts(t *testing.T) {
+	rule := ShardingOrder{}.ShardingRule()
+	all := rule.Algorithm.Broadcast()
+	testCases := []struct {
+		name string
+		ps   []Predicate
+
+		wantDsts []Dst
+		wantErr  error
+	}{
+		{
+			name:     "no where",
+			wantDsts: all,
+		},
+		{
+			name:     "eq",
+			ps:       []Predicate{C("UserId").Eq(3)},
+			wantDsts: []Dst{{DB: "order_db_1", Table: "order_01"}},
+		},
+		{
+			name: "in",
+			ps:   []Predicate{C("UserId").In(3, 4, 7)},
+			wantDsts: []Dst{
+				{DB: "order_db_1", Table: "order_01"},
+				{DB: "order_db_0", Table: "order_00"},
+			},
+		},
+		{
+			name:     "and other column",
+			ps:       []Predicate{C("Amount").GT(10), C("UserId").Eq(4)},
+			wantDsts: []Dst{{DB: "order_db_0", Table: "order_00"}},
+		},
+		{
+			name:     "and conflict",
+			ps:       []Predicate{C("UserId").Eq(4).And(C("UserId").Eq(5))},
+			wantDsts: []Dst{},
+		},
+		{
+			name: "or",
+			ps:   []Predicate{C("UserId").Eq(4).Or(C("UserId").Eq(5))},
+			wantDsts: []Dst{
+				{DB: "order_db_0", Table: "order_00"},
+				{DB: "order_db_0", Table: "order_01"},
+			},
+		},
+		{
+			name:     "or other column",
+			ps:       []Predicate{C("UserId").Eq(4).Or(C("Amount").Eq(5))},
+			wantDsts: all,
+		},
+		{
+			name:     "not eq",
+			ps:       []Predicate{C("UserId").NEQ(4)},
+			wantDsts: all,
+		},
+		{
+			name:     "not",
+			ps:       []Predicate{Not(C("UserId").Eq(4))},
+			wantDsts: all,
+		},
+		{
+			name:    "invalid key",
+			ps:      []Predicate{C("UserId").Eq(1.5)},
+			wantErr: errs.NewErrUnsupportedShardingKey(1.5),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dsts, err := rule.dsts(tc.ps)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantDsts, dsts)
+		})
+	}
+}
+
+func TestShardingDB_Get(t *testing.T) {
+	sd, mocks := shardingMockDB(t)
+
+	mocks["order_db_1"].ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `order_01` WHERE `user_id` = ?;")).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).
+			AddRow(1, 3, 100))
+	res, err := NewSelector[ShardingOrder](sd).
+		Where(C("UserId").Eq(3)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ShardingOrder{Id: 1, UserId: 3, Amount: 100}, res)
+
+	// 没有分片键，每个分片都要查
+	for _, db := range []string{"order_db_0", "order_db_1"} {
+		for _, tbl := range []string{"order_00", "order_01"} {
+			rows := sqlmock.NewRows([]string{"id", "user_id", "amount"})
+			if db == "order_db_1" && tbl == "order_00" {
+				rows.AddRow(2, 2, 200)
+			}
+			mocks[db].ExpectQuery(regexp.QuoteMeta(
+				"SELECT * FROM `" + tbl + "` WHERE `id` = ?;")).
+				WithArgs(2).WillReturnRows(rows)
+		}
+	}
+	res, err = NewSelector[ShardingOrder](sd).
+		Where(C("Id").Eq(2)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ShardingOrder{Id: 2, UserId: 2, Amount: 200}, res)
+
+	// 分片键互相矛盾，不需要查询
+	_, err = NewSelector[ShardingOrder](sd).
+		Where(C("UserId").Eq(4), C("UserId").Eq(5)).Get(context.Background())
+	assert.Equal(t, ErrNoRows, err)
+
+	for _, mock := range mocks {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestShardingDB_GetMulti(t *testing.T) {
+	sd, mocks := shardingMockDB(t)
+
+	// 每个分片取前 offset+limit 行，合并排序之后再分页
+	amounts := map[string][]int{
+		"order_db_0.order_00": {400, 100},
+		"order_db_0.order_01": {500},
+		"order_db_1.order_00": {300, 200},
+		"order_db_1.order_01": {},
+	}
+	id := 0
+	for _, db := range []string{"order_db_0", "order_db_1"} {
+		for _, tbl := range []string{"order_00", "order_01"} {
+			rows := sqlmock.NewRows([]string{"id", "user_id", "amount"})
+			for _, amount := range amounts[db+"."+tbl] {
+				id++
+				rows.AddRow(id, id, amount)
+			}
+			mocks[db].ExpectQuery(regexp.QuoteMeta(
+				"SELECT * FROM `"+tbl+"` WHERE `amount` > ? ORDER BY `amount` DESC LIMIT ?;")).
+				WithArgs(10, 3).WillReturnRows(rows)
+		}
+	}
+	res, err := NewSelector[ShardingOrder](sd).Where(C("Amount").GT(10)).
+		OrderBy(Desc("Amount")).Offset(1).Limit(2).GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*ShardingOrder{
+		{Id: 1, UserId: 1, Amount: 400},
+		{Id: 4, UserId: 4, Amount: 300},
+	}, res)
+
+	// 只有一个分片的时候，分页交给数据库
+	mocks["order_db_0"].ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `order_00` WHERE `user_id` IN (?,?) LIMIT ? OFFSET ?;")).
+		WithArgs(4, 8, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).
+			AddRow(6, 8, 100))
+	res, err = NewSelector[ShardingOrder](sd).Where(C("UserId").In(4, 8)).
+		Offset(1).Limit(2).GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*ShardingOrder{{Id: 6, UserId: 8, Amount: 100}}, res)
+
+	for _, mock := range mocks {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestShardingDB_Insert(t *testing.T) {
+	sd, mocks := shardingMockDB(t)
+
+	mocks["order_db_1"].ExpectExec(regexp.QuoteMeta(
+		"INSERT INTO `order_01`(`id`,`user_id`,`amount`) VALUES (?,?,?),(?,?,?);")).
+		WithArgs(1, 3, 10, 2, 7, 20).
+		WillReturnResult(driver.RowsAffected(2))
+	mocks["order_db_0"].ExpectExec(regexp.QuoteMeta(
+		"INSERT INTO `order_00`(`id`,`user_id`,`amount`) VALUES (?,?,?);")).
+		WithArgs(3, 4, 30).
+		WillReturnResult(driver.RowsAffected(1))
+	res := NewInserter[ShardingOrder](sd).Values(
+		&ShardingOrder{Id: 1, UserId: 3, Amount: 10},
+		&ShardingOrder{Id: 2, UserId: 7, Amount: 20},
+		&ShardingOrder{Id: 3, UserId: 4, Amount: 30},
+	).Exec(context.Background())
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+	_, err = res.LastInsertId()
+	assert.Equal(t, errs.ErrMultiLastInsertId, err)
+
+	for _, mock := range mocks {
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestShardingDB_Errors(t *testing.T) {
+	sd, _ := shardingMockDB(t)
+	_, err := NewSelector[TestModel](sd).Get(context.Background())
+	assert.Equal(t, errs.NewErrNotShardingTable("test_model"), err)
+
+	res := NewInserter[ShardingOrder](sd).Exec(context.Background())
+	assert.Equal(t, errs.ErrInsertZeroRow, res.Err())
+
+	_, err = RawQuery[ShardingOrder](sd, "SELECT 1").Get(context.Background())
+	assert.Equal(t, errs.ErrShardingDirectSQL, err)
+
+	// 没有配置 order_db_1
+	sd = NewShardingDB(map[string]Session{"order_db_0": sd.dbs["order_db_0"]})
+	_, err = NewSelector[ShardingOrder](sd).Where(C("UserId").Eq(3)).GetMulti(context.Background())
+	assert.Equal(t, errs.NewErrUnknownShardingDB("order_db_1"), err)
+}
+
+func shardingMockDB(t *testing.T) (*ShardingDB, map[string]sqlmock.Sqlmock) {
+	dbs := make(map[string]Session, 2)
+	mocks := make(map[string]sqlmock.Sqlmock, 2)
+	for _, name := range []string{"order_db_0", "order_db_1"} {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = mockDB.Close() })
+		// 广播的查询是并发执行的
+		mock.MatchExpectationsInOrder(false)
+		db, err := OpenDB(mockDB)
+		require.NoError(t, err)
+		dbs[name] = db
+		mocks[name] = mock
+	}
+	return NewShardingDB(dbs), mocks
+}
+
+type ShardingOrder struct {
+	Id     int64
+	UserId int64
+	Amount int
+}
+
+func (ShardingOrder) ShardingRule() ShardingRule {
+	return ShardingRule{
+		Key: "UserId",
+		Algorithm: &HashSharding{
+			DBPattern:    "order_db_%d",
+			DBCount:      2,
+			TablePattern: "order_%02d",
+			TableCount:   2,
+		},
+	}
+}
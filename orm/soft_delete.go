@@ -0,0 +1,29 @@
+package orm
+
+import (
+	"github.com/jackycsl/geektime-go-practical/orm/model"
+)
+
+// notDeleted 返回过滤掉软删除数据的条件，例如 `deleted_at` IS NULL
+// 只处理单表，JOIN 和子查询需要用户自己加上条件
+// 第二个返回值代表表上有没有软删除列
+func (b *builder) notDeleted(table TableReference) (Predicate, bool, error) {
+	switch table.(type) {
+	case nil, Table:
+	default:
+		return Predicate{}, false, nil
+	}
+	m, err := b.tableModel(table)
+	if err != nil || m.SoftDelete == nil {
+		return Predicate{}, false, err
+	}
+	return Column{table: table, name: m.SoftDelete.GoName}.IsNull(), true, nil
+}
+
+// tableModel 返回 table 对应的元数据，没有指定的时候就是 T 的元数据
+func (b *builder) tableModel(table TableReference) (*model.Model, error) {
+	if t, ok := table.(Table); ok {
+		return b.r.Get(t.entity)
+	}
+	return b.model, nil
+}
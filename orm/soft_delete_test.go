@@ -0,0 +1,192 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDelete_Build(t *testing.T) {
+	db := memoryDB(t)
+	testCases := []struct {
+		name string
+		b    QueryBuilder
+
+		wantErr   error
+		wantQuery *Query
+	}{
+		{
+			name: "select",
+			b:    NewSelector[SoftDeleteModel](db),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `soft_delete_model` WHERE `deleted_at` IS NULL;",
+			},
+		},
+		{
+			name: "select where",
+			b:    NewSelector[SoftDeleteModel](db).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_model` WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "select unscoped",
+			b:    NewSelector[SoftDeleteModel](db).Where(C("Id").Eq(1)).Unscoped(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "select from alias",
+			b:    NewSelector[TestModel](db).From(TableOf(&SoftDeleteModel{}).As("t")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `soft_delete_model` AS `t` WHERE `t`.`deleted_at` IS NULL;",
+			},
+		},
+		{
+			// JOIN 需要用户自己处理
+			name: "select join",
+			b: NewSelector[SoftDeleteModel](db).From(TableOf(&SoftDeleteModel{}).
+				Join(TableOf(&TestModel{})).Using("Id")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM (`soft_delete_model` JOIN `test_model` USING (`id`));",
+			},
+		},
+		{
+			name: "no soft delete column",
+			b:    NewSelector[TestModel](db),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model`;",
+			},
+		},
+		{
+			name: "delete",
+			b:    NewDeleter[SoftDeleteModel](db),
+			wantQuery: &Query{
+				SQL: "UPDATE `soft_delete_model` SET `deleted_at`=NOW() WHERE `deleted_at` IS NULL;",
+			},
+		},
+		{
+			name: "delete where",
+			b:    NewDeleter[SoftDeleteModel](db).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL: "UPDATE `soft_delete_model` SET `deleted_at`=NOW() " +
+					"WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "delete unscoped",
+			b:    NewDeleter[SoftDeleteModel](db).Where(C("Id").Eq(1)).Unscoped(),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `soft_delete_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "delete from",
+			b:    NewDeleter[TestModel](db).From(TableOf(&SoftDeleteModel{})),
+			wantQuery: &Query{
+				SQL: "UPDATE `soft_delete_model` SET `deleted_at`=NOW() WHERE `deleted_at` IS NULL;",
+			},
+		},
+		{
+			name: "preload",
+			b: &preloadQuery{
+				builder: builder{
+					core:   db.getCore(),
+					quoter: db.dialect.quoter(),
+				},
+				table:      "soft_delete_model",
+				col:        "id",
+				vals:       []any{1, 2},
+				softDelete: "deleted_at",
+			},
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_model` WHERE `id` IN (?,?) AND `deleted_at` IS NULL;",
+				Args: []any{1, 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.b.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestSoftDelete_Dialect(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		wantSQL string
+	}{
+		{
+			name:    "mysql",
+			dialect: DialectMySQL,
+			wantSQL: "UPDATE `soft_delete_model` SET `deleted_at`=NOW() WHERE `deleted_at` IS NULL;",
+		},
+		{
+			name:    "sqlite",
+			dialect: DialectSQLite,
+			wantSQL: "UPDATE `soft_delete_model` SET `deleted_at`=CURRENT_TIMESTAMP WHERE `deleted_at` IS NULL;",
+		},
+		{
+			name:    "postgresql",
+			dialect: DialectPostgreSQL,
+			wantSQL: `UPDATE "soft_delete_model" SET "deleted_at"=NOW() WHERE "deleted_at" IS NULL;`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			q, err := NewDeleter[SoftDeleteModel](db).Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSQL, q.SQL)
+		})
+	}
+}
+
+func TestDeleter_SoftDelete_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	var types []string
+	db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			types = append(types, qc.Type)
+			return next(ctx, qc)
+		}
+	}))
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE .*").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectExec("DELETE .*").WillReturnResult(driver.RowsAffected(1))
+
+	res := NewDeleter[SoftDeleteModel](db).Where(C("Id").Eq(1)).Exec(context.Background())
+	require.NoError(t, res.Err())
+	res = NewDeleter[SoftDeleteModel](db).Where(C("Id").Eq(1)).Unscoped().Exec(context.Background())
+	require.NoError(t, res.Err())
+
+	// 软删除是 UPDATE，所以 nodelete 之类的 middleware 不会拦截
+	assert.Equal(t, []string{"UPDATE", "DELETE"}, types)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type SoftDeleteModel struct {
+	Id        int64
+	DeletedAt sql.NullTime `orm:"soft_delete"`
+}
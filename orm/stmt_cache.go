@@ -0,0 +1,123 @@
+package orm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// stmtCache 按照 SQL 缓存 *sql.Stmt，超过容量的时候淘汰最久没有使用的
+// 正在使用的 Stmt 被淘汰的时候，等用完了再关闭
+type stmtCache struct {
+	mutex sync.Mutex
+	size  int
+	// lru 前面是最近使用的
+	lru   *list.List
+	stmts map[string]*list.Element
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:  size,
+		lru:   list.New(),
+		stmts: make(map[string]*list.Element, size),
+	}
+}
+
+// get 返回的 release 必须在 Stmt 用完之后调用
+// 查询返回的 *sql.Rows 会自己持有 Stmt，所以查询返回之后就可以调用 release
+func (c *stmtCache) get(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, func(), error) {
+	c.mutex.Lock()
+	if elem, ok := c.stmts[query]; ok {
+		entry := c.acquire(elem)
+		c.mutex.Unlock()
+		return entry.stmt, c.releaser(entry), nil
+	}
+	c.mutex.Unlock()
+
+	// 不要在锁里面 Prepare，它需要访问数据库
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mutex.Lock()
+	// 别人已经放进去了，用别人的
+	if elem, ok := c.stmts[query]; ok {
+		entry := c.acquire(elem)
+		c.mutex.Unlock()
+		_ = stmt.Close()
+		return entry.stmt, c.releaser(entry), nil
+	}
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.stmts[query] = c.lru.PushFront(entry)
+	var closing []*sql.Stmt
+	for c.lru.Len() > c.size {
+		if s := c.evict(c.lru.Back()); s != nil {
+			closing = append(closing, s)
+		}
+	}
+	c.mutex.Unlock()
+	for _, s := range closing {
+		_ = s.Close()
+	}
+	return stmt, c.releaser(entry), nil
+}
+
+func (c *stmtCache) acquire(elem *list.Element) *stmtEntry {
+	c.lru.MoveToFront(elem)
+	entry := elem.Value.(*stmtEntry)
+	entry.refs++
+	return entry
+}
+
+// evict 返回需要马上关闭的 Stmt
+func (c *stmtCache) evict(elem *list.Element) *sql.Stmt {
+	entry := elem.Value.(*stmtEntry)
+	c.lru.Remove(elem)
+	delete(c.stmts, entry.query)
+	entry.evicted = true
+	if entry.refs > 0 {
+		return nil
+	}
+	return entry.stmt
+}
+
+func (c *stmtCache) releaser(entry *stmtEntry) func() {
+	return func() {
+		c.mutex.Lock()
+		entry.refs--
+		shouldClose := entry.evicted && entry.refs == 0
+		c.mutex.Unlock()
+		if shouldClose {
+			_ = entry.stmt.Close()
+		}
+	}
+}
+
+// close 关闭所有的 Stmt，正在使用的等用完了再关闭
+func (c *stmtCache) close() error {
+	c.mutex.Lock()
+	var closing []*sql.Stmt
+	for c.lru.Len() > 0 {
+		if s := c.evict(c.lru.Back()); s != nil {
+			closing = append(closing, s)
+		}
+	}
+	c.mutex.Unlock()
+	var err error
+	for _, s := range closing {
+		if e := s.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_StmtCache(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithStmtCache(2))
+	require.NoError(t, err)
+
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id"}).AddRow(1)
+	}
+	// 同样的 SQL 只 Prepare 一次
+	prep := mock.ExpectPrepare(regexp.QuoteMeta("SELECT * FROM `test_model` WHERE `id` = ?;")).
+		WillBeClosed()
+	prep.ExpectQuery().WithArgs(1).WillReturnRows(rows())
+	prep.ExpectQuery().WithArgs(2).WillReturnRows(rows())
+	for id := 1; id <= 2; id++ {
+		_, err = NewSelector[TestModel](db).Where(C("Id").Eq(id)).Get(context.Background())
+		require.NoError(t, err)
+	}
+
+	// 超过容量之后淘汰最久没有使用的
+	mock.ExpectPrepare(regexp.QuoteMeta("SELECT * FROM `test_model` WHERE `age` = ?;")).
+		WillBeClosed().ExpectQuery().WillReturnRows(rows())
+	_, err = NewSelector[TestModel](db).Where(C("Age").Eq(18)).Get(context.Background())
+	require.NoError(t, err)
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO `test_model`(`id`,`first_name`,`age`,`last_name`) VALUES (?,?,?,?);")).
+		WillBeClosed().ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	err = NewInserter[TestModel](db).Values(&TestModel{Id: 1}).Exec(context.Background()).Err()
+	require.NoError(t, err)
+	assert.Equal(t, 2, db.stmts.lru.Len())
+	_, ok := db.stmts.stmts["SELECT * FROM `test_model` WHERE `id` = ?;"]
+	assert.False(t, ok)
+
+	mock.ExpectClose()
+	require.NoError(t, db.Close())
+	assert.Equal(t, 0, db.stmts.lru.Len())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStmtCache_EvictInUse(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", "file:stmt_cache.db?cache=shared&mode=memory")
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	ctx := context.Background()
+	c := newStmtCache(1)
+
+	stmt, release, err := c.get(ctx, sqlDB, "SELECT 1")
+	require.NoError(t, err)
+	// 第二个 Stmt 把第一个挤出去，但是第一个还在使用，不能关闭
+	_, releaseAnother, err := c.get(ctx, sqlDB, "SELECT 2")
+	require.NoError(t, err)
+	releaseAnother()
+	var val int
+	require.NoError(t, stmt.QueryRowContext(ctx).Scan(&val))
+	assert.Equal(t, 1, val)
+
+	// 用完之后关闭
+	release()
+	assert.Error(t, stmt.QueryRowContext(ctx).Scan(&val))
+}
+
+func TestTx_StmtCache(t *testing.T) {
+	db, err := Open("sqlite3", "file:stmt_cache_tx.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite), DBWithStmtCache(8))
+	require.NoError(t, err)
+	_, err = db.db.Exec("CREATE TABLE savepoint_model(id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		for id := int64(1); id <= 3; id++ {
+			if err := NewInserter[SavepointModel](tx).Values(&SavepointModel{Id: id}).Exec(ctx).Err(); err != nil {
+				return err
+			}
+		}
+		res, err := NewSelector[SavepointModel](tx).Where(C("Id").GT(1)).GetMulti(ctx)
+		if err != nil {
+			return err
+		}
+		assert.Len(t, res, 2)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	// 一条 INSERT，一条 SELECT
+	assert.Equal(t, 2, db.stmts.lru.Len())
+
+	require.NoError(t, db.Close())
+	_, err = NewSelector[SavepointModel](db).Get(ctx)
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package orm
+
+// Subquery 代表子查询，目前只能用在 IN 和 NOT IN 里面
+type Subquery struct {
+	s subqueryBuilder
+}
+
+// subqueryBuilder 子查询的占位符要接着外层查询的参数往下数
+type subqueryBuilder interface {
+	QueryBuilder
+	setArgOffset(offset int)
+	tables() ([]string, error)
+}
+
+func (Subquery) expr() {}
+
+// subqueryTables 把表达式里面的子查询涉及的表追加到 tables 里面
+func subqueryTables(tables []string, expr Expression) ([]string, error) {
+	var err error
+	switch exp := expr.(type) {
+	case Predicate:
+		if tables, err = subqueryTables(tables, exp.left); err != nil {
+			return nil, err
+		}
+		return subqueryTables(tables, exp.right)
+	case MathExpr:
+		if tables, err = subqueryTables(tables, exp.left); err != nil {
+			return nil, err
+		}
+		return subqueryTables(tables, exp.right)
+	case FuncExpr:
+		for _, arg := range exp.args {
+			if tables, err = subqueryTables(tables, arg); err != nil {
+				return nil, err
+			}
+		}
+	case Subquery:
+		subTables, err := exp.s.tables()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range subTables {
+			tables = appendTable(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+// appendTable 追加表名，已经有了就不再追加
+func appendTable(tables []string, table string) []string {
+	for _, t := range tables {
+		if t == table {
+			return tables
+		}
+	}
+	return append(tables, table)
+}
@@ -3,6 +3,9 @@ package orm
 import (
 	"context"
 	"database/sql"
+	"strconv"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
 )
 
 var (
@@ -22,18 +25,122 @@ type Tx struct {
 
 	// 给事务扩散方案
 	done bool
+	// savepoints 已经创建的 SAVEPOINT 的数量，用来生成不重复的名字
+	savepoints int
+
+	// ctx 开启事务的 ctx，回调的时候传给回调
+	ctx        context.Context
+	onCommit   []func(ctx context.Context)
+	onRollback []func(ctx context.Context)
+}
+
+// OnCommit 注册提交成功之后执行的回调，例如失效缓存、发送消息
+// 回调按照注册的顺序执行，提交失败或者回滚都不会执行
+func (t *Tx) OnCommit(fn func(ctx context.Context)) {
+	t.onCommit = append(t.onCommit, fn)
+}
+
+// OnRollback 注册回滚成功之后执行的回调
+// 在 SAVEPOINT 里面注册的回调，回滚到 SAVEPOINT 的时候就会执行
+func (t *Tx) OnRollback(fn func(ctx context.Context)) {
+	t.onRollback = append(t.onRollback, fn)
+}
+
+// txOf 查询实际执行的事务，Cluster 使用的是 ctx 里面主库的事务
+func txOf(ctx context.Context, sess Session) *Tx {
+	switch s := sess.(type) {
+	case *Tx:
+		return s
+	case *Cluster:
+		tx, _ := s.primary.txFrom(ctx)
+		return tx
+	}
+	return nil
+}
+
+func runCallbacks(ctx context.Context, fns []func(ctx context.Context)) {
+	for _, fn := range fns {
+		fn(ctx)
+	}
+}
+
+// Propagation 事务的传播方式，决定 ctx 里面已经有事务的时候怎么办
+type Propagation int
+
+const (
+	// PropagationRequired 有事务就加入，没有就开启新事务
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew 总是开启新事务，和外层事务互不影响
+	PropagationRequiresNew
+	// PropagationNested 有事务就创建 SAVEPOINT，fn 失败的时候只回滚自己的部分
+	// 没有事务就开启新事务
+	PropagationNested
+	// PropagationNever 不在事务里面执行，fn 拿到的 tx 是 nil
+	// ctx 里面有事务的时候返回错误
+	PropagationNever
+)
+
+// doSavepoint fn 失败或者 panic 的时候回滚到 SAVEPOINT，外层事务可以继续使用
+func (t *Tx) doSavepoint(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error) (err error) {
+	t.savepoints++
+	name := "sp_" + strconv.Itoa(t.savepoints)
+	if _, err = t.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	// 回滚到 SAVEPOINT 之后，这之后注册的回调都要丢弃
+	commits, rollbacks := len(t.onCommit), len(t.onRollback)
+	panicked := true
+	defer func() {
+		if panicked || err != nil {
+			_, e := t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			if e == nil {
+				runCallbacks(t.ctx, t.onRollback[rollbacks:])
+				t.onCommit, t.onRollback = t.onCommit[:commits], t.onRollback[:rollbacks]
+			}
+			err = errs.NewErrFailedToRollbackTx(err, e, panicked)
+		} else {
+			_, err = t.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+		}
+	}()
+	err = fn(ctx, t)
+	panicked = false
+	return err
 }
 
 func (t *Tx) getCore() core {
 	return t.db.core
 }
 
-func (t *Tx) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return t.tx.QueryContext(ctx, query, args...)
+func (t *Tx) queryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	defer func() {
+		err = t.db.dialect.translateErr(err)
+	}()
+	if t.db.stmts == nil {
+		return t.tx.QueryContext(ctx, query, args...)
+	}
+	stmt, release, err := t.db.stmts.get(ctx, t.db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// 事务里面的 Stmt 在事务结束的时候自动关闭
+	return t.tx.StmtContext(ctx, stmt).QueryContext(ctx, args...)
 }
 
-func (t *Tx) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return t.tx.ExecContext(ctx, query, args...)
+func (t *Tx) execContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	defer func() {
+		err = t.db.dialect.translateErr(err)
+	}()
+	if t.db.stmts == nil {
+		return t.tx.ExecContext(ctx, query, args...)
+	}
+	stmt, release, err := t.db.stmts.get(ctx, t.db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return t.tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
 }
 
 func (t *Tx) Commit() error {
@@ -42,12 +149,21 @@ func (t *Tx) Commit() error {
 	// 	return t.tx.Commit()
 	// }
 	t.done = true
-	return t.tx.Commit()
+	// 提交的时候才发现死锁或者序列化失败，也要翻译
+	if err := t.tx.Commit(); err != nil {
+		return t.db.dialect.translateErr(err)
+	}
+	runCallbacks(t.ctx, t.onCommit)
+	return nil
 }
 
 func (t *Tx) Rollback() error {
 	t.done = true
-	return t.tx.Rollback()
+	if err := t.tx.Rollback(); err != nil {
+		return t.db.dialect.translateErr(err)
+	}
+	runCallbacks(t.ctx, t.onRollback)
+	return nil
 }
 
 func (t *Tx) RollbackIfNotCommit() error {
@@ -56,5 +172,8 @@ func (t *Tx) RollbackIfNotCommit() error {
 	if err == sql.ErrTxDone {
 		return nil
 	}
-	return err
+	if err == nil {
+		runCallbacks(t.ctx, t.onRollback)
+	}
+	return t.db.dialect.translateErr(err)
 }
@@ -0,0 +1,136 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx_Callbacks(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(mock sqlmock.Sqlmock)
+		// do 注册回调，然后提交或者回滚
+		do func(db *DB, record func(name string) func(ctx context.Context)) error
+
+		wantCalled []string
+	}{
+		{
+			name: "commit",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			do: func(db *DB, record func(name string) func(ctx context.Context)) error {
+				tx, err := db.BeginTx(context.Background(), nil)
+				if err != nil {
+					return err
+				}
+				tx.OnCommit(record("commit 1"))
+				tx.OnRollback(record("rollback"))
+				tx.OnCommit(record("commit 2"))
+				return tx.Commit()
+			},
+			wantCalled: []string{"commit 1", "commit 2"},
+		},
+		{
+			name: "commit error",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
+			},
+			do: func(db *DB, record func(name string) func(ctx context.Context)) error {
+				tx, err := db.BeginTx(context.Background(), nil)
+				if err != nil {
+					return err
+				}
+				tx.OnCommit(record("commit"))
+				_ = tx.Commit()
+				return nil
+			},
+		},
+		{
+			name: "rollback",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			do: func(db *DB, record func(name string) func(ctx context.Context)) error {
+				tx, err := db.BeginTx(context.Background(), nil)
+				if err != nil {
+					return err
+				}
+				tx.OnCommit(record("commit"))
+				tx.OnRollback(record("rollback"))
+				return tx.RollbackIfNotCommit()
+			},
+			wantCalled: []string{"rollback"},
+		},
+		{
+			name: "do tx",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			do: func(db *DB, record func(name string) func(ctx context.Context)) error {
+				_ = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+					tx.OnCommit(record("commit"))
+					tx.OnRollback(record("rollback"))
+					return errors.New("biz error")
+				}, nil)
+				return nil
+			},
+			wantCalled: []string{"rollback"},
+		},
+		{
+			// 回滚到 SAVEPOINT 的时候，里面注册的回调要么马上执行，要么丢弃
+			name: "nested rollback",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT sp_1")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT sp_1")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			do: func(db *DB, record func(name string) func(ctx context.Context)) error {
+				return db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+					tx.OnCommit(record("outer commit"))
+					tx.OnRollback(record("outer rollback"))
+					_ = db.DoTxWithPropagation(ctx, PropagationNested, func(ctx context.Context, tx *Tx) error {
+						tx.OnCommit(record("nested commit"))
+						tx.OnRollback(record("nested rollback"))
+						return errors.New("biz error")
+					}, nil)
+					return nil
+				}, nil)
+			},
+			wantCalled: []string{"nested rollback", "outer commit"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			var called []string
+			record := func(name string) func(ctx context.Context) {
+				return func(ctx context.Context) {
+					called = append(called, name)
+				}
+			}
+			require.NoError(t, tc.do(db, record))
+			assert.Equal(t, tc.wantCalled, called)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+)
+
+// Backoff 决定第 attempt 次失败之后，等多久再重试
+// 同一个 Backoff 会被并发使用，所以不要在里面保存状态
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// FixedBackoff 每次都等待固定的时间
+type FixedBackoff struct {
+	Interval time.Duration
+}
+
+func (f FixedBackoff) Next(attempt int) time.Duration {
+	return f.Interval
+}
+
+// ExponentialBackoff 每次等待的时间翻倍，最多等待 Max
+type ExponentialBackoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (e ExponentialBackoff) Next(attempt int) time.Duration {
+	interval := e.Initial
+	for i := 1; i < attempt; i++ {
+		interval *= 2
+		if e.Max > 0 && interval >= e.Max {
+			return e.Max
+		}
+	}
+	return interval
+}
+
+type TxRetryOption func(r *txRetry)
+
+type txRetry struct {
+	maxAttempts int
+	backoff     Backoff
+}
+
+// TxRetryWithMaxAttempts 最多执行 n 次，包括第一次
+func TxRetryWithMaxAttempts(n int) TxRetryOption {
+	return func(r *txRetry) {
+		r.maxAttempts = n
+	}
+}
+
+func TxRetryWithBackoff(backoff Backoff) TxRetryOption {
+	return func(r *txRetry) {
+		r.backoff = backoff
+	}
+}
+
+type txAttemptKey struct{}
+
+// TxAttemptFromContext 返回 DoTxWithRetry 当前是第几次执行，从 1 开始
+// fn 里面的查询经过 Middleware 的时候，可以用它区分重试
+// 不是 DoTxWithRetry 里面的查询返回 0
+func TxAttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(txAttemptKey{}).(int)
+	return attempt
+}
+
+// DoTxWithRetry 在死锁或者序列化失败的时候，回滚之后重新执行整个事务
+// 所以 fn 必须可以重复执行
+// ctx 里面已经有事务的时候直接加入，不会重试，死锁会回滚整个事务，只能由最外层重试
+func (db *DB) DoTxWithRetry(ctx context.Context,
+	fn func(ctx context.Context, tx *Tx) error,
+	opts *sql.TxOptions, retryOpts ...TxRetryOption) error {
+	if tx, ok := db.txFrom(ctx); ok {
+		return fn(ctx, tx)
+	}
+	r := &txRetry{
+		maxAttempts: 3,
+		backoff: ExponentialBackoff{
+			Initial: 10 * time.Millisecond,
+			Max:     time.Second,
+		},
+	}
+	for _, opt := range retryOpts {
+		opt(r)
+	}
+	for attempt := 1; ; attempt++ {
+		err := db.doTx(context.WithValue(ctx, txAttemptKey{}, attempt), fn, opts)
+		if err == nil || attempt >= r.maxAttempts || !db.retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(r.backoff.Next(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable 查询和提交的错误都已经翻译过了
+// 但是 fn 可能直接返回驱动的错误，所以这里再翻译一遍
+func (db *DB) retryable(err error) bool {
+	err = db.dialect.translateErr(err)
+	return errors.Is(err, errs.ErrDeadlock) || errors.Is(err, errs.ErrSerializationFailure)
+}
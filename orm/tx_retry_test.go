@@ -0,0 +1,193 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_DoTxWithRetry(t *testing.T) {
+	bizErr := errors.New("biz error")
+	deadlock := &mysql.MySQLError{Number: 1213}
+	testCases := []struct {
+		name string
+		mock func(mock sqlmock.Sqlmock)
+
+		wantErr      error
+		wantAttempts []int
+	}{
+		{
+			name: "no error",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantAttempts: []int{1},
+		},
+		{
+			name: "retry deadlock",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT .*").WillReturnError(deadlock)
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantAttempts: []int{1, 2},
+		},
+		{
+			// 提交的时候才发现冲突
+			name: "retry commit deadlock",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit().WillReturnError(deadlock)
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantAttempts: []int{1, 2},
+		},
+		{
+			name: "max attempts",
+			mock: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 3; i++ {
+					mock.ExpectBegin()
+					mock.ExpectExec("INSERT .*").WillReturnError(deadlock)
+					mock.ExpectRollback()
+				}
+			},
+			wantErr:      ErrDeadlock,
+			wantAttempts: []int{1, 2, 3},
+		},
+		{
+			name: "not retryable",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT .*").WillReturnError(bizErr)
+				mock.ExpectRollback()
+			},
+			wantErr:      bizErr,
+			wantAttempts: []int{1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			var attempts []int
+			// Middleware 可以观察到重试
+			db, err := OpenDB(mockDB, DBWithMiddlewares(func(next Handler) Handler {
+				return func(ctx context.Context, qc *QueryContext) *QueryResult {
+					attempts = append(attempts, TxAttemptFromContext(ctx))
+					return next(ctx, qc)
+				}
+			}))
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			err = db.DoTxWithRetry(context.Background(), func(ctx context.Context, tx *Tx) error {
+				return NewInserter[TestModel](tx).Values(&TestModel{Id: 1}).Exec(ctx).Err()
+			}, nil, TxRetryWithMaxAttempts(3), TxRetryWithBackoff(FixedBackoff{Interval: time.Millisecond}))
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tc.wantErr))
+			}
+			assert.Equal(t, tc.wantAttempts, attempts)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDB_DoTxWithRetry_Joined(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	// 加入外层事务的时候不重试，交给外层处理
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(&mysql.MySQLError{Number: 1213})
+	mock.ExpectRollback()
+	cnt := 0
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		return db.DoTxWithRetry(ctx, func(ctx context.Context, inner *Tx) error {
+			cnt++
+			assert.Same(t, tx, inner)
+			return NewInserter[TestModel](inner).Values(&TestModel{Id: 1}).Exec(ctx).Err()
+		}, nil)
+	}, nil)
+	assert.True(t, errors.Is(err, ErrDeadlock))
+	assert.Equal(t, 1, cnt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_DoTxWithRetry_ContextCanceled(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	ctx, cancel := context.WithCancel(context.Background())
+	err = db.DoTxWithRetry(ctx, func(ctx context.Context, tx *Tx) error {
+		// 等待重试的时候被取消
+		cancel()
+		return &mysql.MySQLError{Number: 1213}
+	}, nil, TxRetryWithBackoff(FixedBackoff{Interval: time.Minute}))
+	assert.Equal(t, context.Canceled, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExponentialBackoff_Next(t *testing.T) {
+	testCases := []struct {
+		name    string
+		backoff ExponentialBackoff
+		attempt int
+
+		wantInterval time.Duration
+	}{
+		{
+			name:         "first",
+			backoff:      ExponentialBackoff{Initial: 10 * time.Millisecond, Max: time.Second},
+			attempt:      1,
+			wantInterval: 10 * time.Millisecond,
+		},
+		{
+			name:         "third",
+			backoff:      ExponentialBackoff{Initial: 10 * time.Millisecond, Max: time.Second},
+			attempt:      3,
+			wantInterval: 40 * time.Millisecond,
+		},
+		{
+			name:         "max",
+			backoff:      ExponentialBackoff{Initial: 10 * time.Millisecond, Max: time.Second},
+			attempt:      10,
+			wantInterval: time.Second,
+		},
+		{
+			name:         "no max",
+			backoff:      ExponentialBackoff{Initial: time.Millisecond},
+			attempt:      11,
+			wantInterval: 1024 * time.Millisecond,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantInterval, tc.backoff.Next(tc.attempt))
+		})
+	}
+}
@@ -29,3 +29,8 @@ type Query struct {
 	SQL  string
 	Args []any
 }
+
+// Build 已经构造好的语句可以直接作为 QueryBuilder 使用
+func (q *Query) Build() (*Query, error) {
+	return q, nil
+}
@@ -0,0 +1,222 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+)
+
+// Updater 用于构造 UPDATE 语句
+type Updater[T any] struct {
+	builder
+	sess    Session
+	val     *T
+	assigns []Assignable
+	where   []Predicate
+}
+
+func NewUpdater[T any](sess Session) *Updater[T] {
+	c := sess.getCore()
+	return &Updater[T]{
+		builder: builder{
+			core:   c,
+			quoter: c.dialect.quoter(),
+		},
+		sess: sess,
+	}
+}
+
+// Update 指定更新的实体
+// Set(C("Age")) 这种形态会从这个实体里面读取值
+func (u *Updater[T]) Update(t *T) *Updater[T] {
+	u.val = t
+	return u
+}
+
+// Set 指定要更新的列
+// C("Age") 代表用 Update 传入的实体的值
+// Assign("Age", 18) 代表直接使用指定的值
+func (u *Updater[T]) Set(assigns ...Assignable) *Updater[T] {
+	u.assigns = assigns
+	return u
+}
+
+func (u *Updater[T]) Where(ps ...Predicate) *Updater[T] {
+	u.where = ps
+	return u
+}
+
+func (u *Updater[T]) Build() (*Query, error) {
+	if len(u.assigns) == 0 {
+		return nil, errs.ErrNoUpdatedColumns
+	}
+	entity := u.val
+	if entity == nil {
+		entity = new(T)
+	}
+	if u.model == nil {
+		var err error
+		u.model, err = u.r.Get(entity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	u.reset()
+	u.sb.WriteString("UPDATE ")
+	u.quote(u.model.TableName)
+	u.sb.WriteString(" SET ")
+	val := u.creator(u.model, entity)
+	for idx, assign := range u.assigns {
+		if idx > 0 {
+			u.sb.WriteByte(',')
+		}
+		if u.isVersion(assign) {
+			return nil, errs.ErrUpdateVersion
+		}
+		switch a := assign.(type) {
+		case Column:
+			if err := u.buildColumn(Column{name: a.name}); err != nil {
+				return nil, err
+			}
+			// 没有实体的话只能写入零值，这往往不是用户想要的
+			if u.val == nil {
+				return nil, errs.ErrNoUpdatedEntity
+			}
+			u.sb.WriteByte('=')
+			arg, err := val.Field(a.name)
+			if err != nil {
+				return nil, err
+			}
+			u.parameter(arg)
+		case Assignment:
+			if err := u.buildColumn(Column{name: a.col}); err != nil {
+				return nil, err
+			}
+			u.sb.WriteByte('=')
+			if err := u.buildExpression(valueOf(a.val)); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errs.NewErrUnsupportedAssignable(assign)
+		}
+	}
+
+	where := u.where
+	if version := u.model.Version; version != nil {
+		// `version`=`version`+1
+		u.sb.WriteByte(',')
+		u.quote(version.ColName)
+		u.sb.WriteByte('=')
+		u.quote(version.ColName)
+		u.sb.WriteString("+1")
+		// 只有传入了实体才知道原来的版本
+		if u.val != nil {
+			cur, err := val.Field(version.GoName)
+			if err != nil {
+				return nil, err
+			}
+			where = append(where[:len(where):len(where)], C(version.GoName).Eq(cur))
+		}
+	}
+
+	if len(where) > 0 {
+		u.sb.WriteString(" WHERE ")
+		if err := u.buildPredicates(where); err != nil {
+			return nil, err
+		}
+	}
+
+	u.sb.WriteByte(';')
+	return &Query{
+		SQL:  u.sb.String(),
+		Args: u.args,
+	}, nil
+}
+
+func (u *Updater[T]) Exec(ctx context.Context) Result {
+	var err error
+	u.model, err = u.r.Get(new(T))
+	if err != nil {
+		return Result{
+			err: err,
+		}
+	}
+
+	qc := &QueryContext{
+		Type:     "UPDATE",
+		Builder:  u,
+		Model:    u.model,
+		HasWhere: len(u.where) > 0,
+	}
+	if u.val != nil {
+		if err = beforeUpdate(ctx, qc, u.val); err != nil {
+			return Result{
+				err: err,
+			}
+		}
+	}
+	res := exec(ctx, u.sess, u.core, qc)
+	var sqlRes sql.Result
+	if res.Result != nil {
+		sqlRes = res.Result.(sql.Result)
+	}
+	if res.Err == nil && sqlRes != nil && u.val != nil && u.model.Version != nil {
+		if err = u.checkVersion(sqlRes); err != nil {
+			return Result{
+				err: err,
+				res: sqlRes,
+			}
+		}
+	}
+	if res.Err == nil && u.val != nil {
+		if err = afterUpdate(ctx, qc, u.val); err != nil {
+			return Result{
+				err: err,
+				res: sqlRes,
+			}
+		}
+	}
+	return Result{
+		err: res.Err,
+		res: sqlRes,
+	}
+}
+
+func (u *Updater[T]) isVersion(assign Assignable) bool {
+	version := u.model.Version
+	if version == nil {
+		return false
+	}
+	switch a := assign.(type) {
+	case Column:
+		return a.name == version.GoName
+	case Assignment:
+		return a.col == version.GoName
+	}
+	return false
+}
+
+// checkVersion 没有影响任何行就是乐观锁冲突
+// 更新成功之后，实体里面的版本也要加一，这样才能继续用这个实体更新
+func (u *Updater[T]) checkVersion(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errs.ErrOptimisticLockConflict
+	}
+	fd, err := reflect.ValueOf(u.val).Elem().FieldByIndexErr(u.model.Version.Index)
+	if err != nil {
+		return err
+	}
+	if fd.CanInt() {
+		fd.SetInt(fd.Int() + 1)
+	} else {
+		fd.SetUint(fd.Uint() + 1)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackycsl/geektime-go-practical/orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdater_Build(t *testing.T) {
+	db := memoryDB(t)
+	testCases := []struct {
+		name string
+		u    QueryBuilder
+
+		wantErr   error
+		wantQuery *Query
+	}{
+		{
+			name:    "no columns",
+			u:       NewUpdater[TestModel](db),
+			wantErr: errs.ErrNoUpdatedColumns,
+		},
+		{
+			name: "single column",
+			u: NewUpdater[TestModel](db).Update(&TestModel{
+				Age: 18,
+			}).Set(C("Age")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?;",
+				Args: []any{int8(18)},
+			},
+		},
+		{
+			name: "assignment",
+			u: NewUpdater[TestModel](db).Update(&TestModel{
+				Age:       18,
+				FirstName: "Tom",
+			}).Set(C("Age"), Assign("FirstName", "DaMing")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?,`first_name`=?;",
+				Args: []any{int8(18), "DaMing"},
+			},
+		},
+		{
+			name: "where",
+			u: NewUpdater[TestModel](db).Update(&TestModel{
+				Age:       18,
+				FirstName: "Tom",
+			}).Set(C("Age"), Assign("FirstName", "DaMing")).
+				Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?,`first_name`=? WHERE `id` = ?;",
+				Args: []any{int8(18), "DaMing", 1},
+			},
+		},
+		{
+			name: "raw expression",
+			u: NewUpdater[TestModel](db).
+				Set(Assign("Age", Raw("`age`+?", 1))).
+				Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=(`age`+?) WHERE `id` = ?;",
+				Args: []any{1, 1},
+			},
+		},
+		{
+			name: "incremental",
+			u: NewUpdater[TestModel](db).
+				Set(Assign("Age", C("Age").Add(1)), Assign("FirstName", Fn("UPPER", C("FirstName")))).
+				Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=`age` + ?,`first_name`=UPPER(`first_name`) WHERE `id` = ?;",
+				Args: []any{1, 1},
+			},
+		},
+		{
+			name:    "invalid column",
+			u:       NewUpdater[TestModel](db).Set(C("Invalid")),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name:    "column without entity",
+			u:       NewUpdater[TestModel](db).Set(C("Age")),
+			wantErr: errs.ErrNoUpdatedEntity,
+		},
+		{
+			name:    "invalid assignment",
+			u:       NewUpdater[TestModel](db).Set(Assign("Invalid", 18)),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.u.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestUpdater_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	testCases := []struct {
+		name     string
+		u        *Updater[TestModel]
+		wantErr  error
+		affected int64
+	}{
+		{
+			name:    "query error",
+			u:       NewUpdater[TestModel](db).Set(C("Invalid")),
+			wantErr: errs.NewErrUnknownField("Invalid"),
+		},
+		{
+			name: "db error",
+			u: func() *Updater[TestModel] {
+				mock.ExpectExec("UPDATE .*").
+					WillReturnError(errors.New("db error"))
+				return NewUpdater[TestModel](db).Set(Assign("Age", 18))
+			}(),
+			wantErr: errors.New("db error"),
+		},
+		{
+			name: "exec",
+			u: func() *Updater[TestModel] {
+				res := driver.RowsAffected(1)
+				mock.ExpectExec("UPDATE .*").
+					WillReturnResult(res)
+				return NewUpdater[TestModel](db).Set(Assign("Age", 18)).
+					Where(C("Id").Eq(1))
+			}(),
+			affected: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := tc.u.Exec(context.Background())
+			affected, err := res.RowsAffected()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.affected, affected)
+		})
+	}
+}
+
+func TestUpdater_Version(t *testing.T) {
+	db := memoryDB(t)
+	testCases := []struct {
+		name string
+		u    QueryBuilder
+
+		wantErr   error
+		wantQuery *Query
+	}{
+		{
+			name: "entity",
+			u: NewUpdater[VersionModel](db).Update(&VersionModel{
+				Id:      1,
+				Stock:   10,
+				Version: 3,
+			}).Set(C("Stock")).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `version_model` SET `stock`=?,`version`=`version`+1 WHERE (`id` = ?) AND (`version` = ?);",
+				Args: []any{10, 1, int64(3)},
+			},
+		},
+		{
+			name: "entity without where",
+			u: NewUpdater[VersionModel](db).Update(&VersionModel{
+				Stock:   10,
+				Version: 3,
+			}).Set(C("Stock")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `version_model` SET `stock`=?,`version`=`version`+1 WHERE `version` = ?;",
+				Args: []any{10, int64(3)},
+			},
+		},
+		{
+			name: "set version",
+			u: NewUpdater[VersionModel](db).Update(&VersionModel{Id: 1, Version: 3}).
+				Set(C("Version")).Where(C("Id").Eq(1)),
+			wantErr: errs.ErrUpdateVersion,
+		},
+		{
+			name: "assign version",
+			u: NewUpdater[VersionModel](db).
+				Set(Assign("Stock", 10), Assign("Version", 5)).Where(C("Id").Eq(1)),
+			wantErr: errs.ErrUpdateVersion,
+		},
+		{
+			// 没有实体就不知道原来的版本，只能加一
+			name: "no entity",
+			u: NewUpdater[VersionModel](db).
+				Set(Assign("Stock", C("Stock").Sub(1))).Where(C("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `version_model` SET `stock`=`stock` - ?,`version`=`version`+1 WHERE `id` = ?;",
+				Args: []any{1, 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.u.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestUpdater_Version_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		affected int64
+
+		wantErr     error
+		wantVersion int64
+	}{
+		{
+			name:        "updated",
+			affected:    1,
+			wantVersion: 4,
+		},
+		{
+			name:        "conflict",
+			affected:    0,
+			wantErr:     ErrOptimisticLockConflict,
+			wantVersion: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectExec("UPDATE .*").
+				WithArgs(10, 1, int64(3)).
+				WillReturnResult(driver.RowsAffected(tc.affected))
+			entity := &VersionModel{Id: 1, Stock: 10, Version: 3}
+			res := NewUpdater[VersionModel](db).Update(entity).
+				Set(C("Stock")).Where(C("Id").Eq(1)).Exec(context.Background())
+			assert.True(t, errors.Is(res.Err(), tc.wantErr))
+			assert.Equal(t, tc.wantVersion, entity.Version)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type VersionModel struct {
+	Id      int64
+	Stock   int
+	Version int64 `orm:"version"`
+}